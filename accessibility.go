@@ -0,0 +1,120 @@
+package markstruct
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// AccessibilityRules returns rules checking Markdown for common
+// accessibility problems: ImageAltText, DescriptiveLinkText,
+// HeadingIncrement and TableHeaders.  Use them with Lint, or with WithRules
+// to have ValidateFields enforce them.  Rules are checked once per name, so
+// AccessibilityRules may be combined with DefaultRules, which also holds
+// HeadingIncrement.
+func AccessibilityRules() []Rule {
+	return []Rule{
+		ImageAltText,
+		DescriptiveLinkText,
+		HeadingIncrement,
+		TableHeaders,
+	}
+}
+
+var (
+	// ImageAltText reports images with empty alt text.
+	ImageAltText Rule = imageAltTextRule{}
+
+	// DescriptiveLinkText reports links whose text does not describe their
+	// destination, such as "click here" or "read more".
+	DescriptiveLinkText Rule = descriptiveLinkTextRule{}
+
+	// TableHeaders reports tables without a header row.  Tables are only
+	// recognized when the `goldmark` Table extension is enabled.
+	TableHeaders Rule = tableHeadersRule{}
+)
+
+// nonDescriptiveLinkText lists lowercased link texts which say nothing about
+// where a link leads.
+var nonDescriptiveLinkText = map[string]bool{
+	"click here": true,
+	"click":      true,
+	"here":       true,
+	"link":       true,
+	"learn more": true,
+	"more":       true,
+	"read more":  true,
+	"this":       true,
+	"this link":  true,
+}
+
+type imageAltTextRule struct{}
+
+func (imageAltTextRule) Name() string { return "image-alt-text" }
+
+func (imageAltTextRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	walkNodes(doc, func(n ast.Node) {
+		image, ok := n.(*ast.Image)
+		if !ok {
+			return
+		}
+
+		if len(bytes.TrimSpace(image.Text(source))) == 0 {
+			diags = append(diags, nodeDiagnostic(n, source,
+				"image %q has no alt text", image.Destination))
+		}
+	})
+
+	return diags
+}
+
+type descriptiveLinkTextRule struct{}
+
+func (descriptiveLinkTextRule) Name() string { return "descriptive-link-text" }
+
+func (descriptiveLinkTextRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	walkNodes(doc, func(n ast.Node) {
+		if _, ok := n.(*ast.Link); !ok {
+			return
+		}
+
+		linktext := strings.ToLower(strings.Join(strings.Fields(string(n.Text(source))), " "))
+		linktext = strings.Trim(linktext, ".!:;,")
+
+		if nonDescriptiveLinkText[linktext] {
+			diags = append(diags, nodeDiagnostic(n, source,
+				"link text %q does not describe its destination", linktext))
+		}
+	})
+
+	return diags
+}
+
+type tableHeadersRule struct{}
+
+func (tableHeadersRule) Name() string { return "table-headers" }
+
+func (tableHeadersRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	walkNodes(doc, func(n ast.Node) {
+		if n.Kind() != east.KindTable {
+			return
+		}
+
+		header := n.FirstChild()
+		if header == nil || header.Kind() != east.KindTableHeader || len(bytes.TrimSpace(header.Text(source))) == 0 {
+			d := nodeDiagnostic(n, source, "table has no header row")
+			d.Column = 1 // tables start at the beginning of their first line
+			diags = append(diags, d)
+		}
+	})
+
+	return diags
+}
@@ -0,0 +1,64 @@
+package markstruct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestAccessibilityRules(t *testing.T) {
+	type Page struct {
+		Intro string   `markdown:"on"`
+		Cards []string `markdown:"on"`
+		Data  string   `markdown:"on"`
+	}
+
+	page := &Page{
+		Intro: "# Welcome\n\n![](hero.png) ![Team photo](team.png)",
+		Cards: []string{
+			"To read the docs [click here](/docs).",
+			"See [the install guide](/install) or [Read more!](/more)",
+			"## Pricing\n\n#### Plans",
+		},
+		Data: "|   |   |\n|---|---|\n| a | b |\n\n| Name | Age |\n|---|---|\n| Al | 42 |",
+	}
+
+	conv := WithMarkdown(goldmark.New(goldmark.WithExtensions(extension.Table)))
+
+	diags, err := conv.Lint(page, AccessibilityRules()...)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			{Path: "Intro", Rule: "image-alt-text", Line: 3, Column: 1, Message: `image "hero.png" has no alt text`},
+			{Path: "Cards[0]", Rule: "descriptive-link-text", Line: 1, Column: 18, Message: `link text "click here" does not describe its destination`},
+			{Path: "Cards[1]", Rule: "descriptive-link-text", Line: 1, Column: 38, Message: `link text "read more" does not describe its destination`},
+			{Path: "Cards[2]", Rule: "heading-increment", Line: 3, Column: 1, Message: "heading level jumps from h2 to h4"},
+			{Path: "Data", Rule: "table-headers", Line: 1, Column: 1, Message: "table has no header row"},
+		},
+		diags,
+	)
+}
+
+func TestAccessibilityRulesWithDefaultRules(t *testing.T) {
+	page := &struct {
+		Body string `markdown:"on"`
+	}{
+		Body: "## Pricing\n\n#### Plans",
+	}
+
+	diags, err := Lint(page, append(DefaultRules(), AccessibilityRules()...)...)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			{Path: "Body", Rule: "heading-increment", Line: 3, Column: 1, Message: "heading level jumps from h2 to h4"},
+		},
+		diags,
+	)
+}
@@ -0,0 +1,60 @@
+package markstruct
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// isBytes reports whether v is a byte slice holding Markdown.  JSON held by
+// a json.RawMessage is never treated as Markdown.
+func isBytes(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice &&
+		v.Type().Elem().Kind() == reflect.Uint8 &&
+		v.Type() != rawMessageType
+}
+
+// convertBytes converts a byte slice as a single Markdown document.  Nil
+// slices are left untouched, as are byte slices of fields not explicitly
+// tagged, which ConvertAllFields would otherwise render from binary data
+// such as hashes or IP addresses.
+func (f *fieldProcessor) convertBytes(v reflect.Value) (bool, error) {
+	if !f.field.Options.Enabled || !isValidSettable(v) || v.IsNil() {
+		return false, nil
+	}
+
+	source := v.Bytes()
+	rendered, err := f.visitBytes(source)
+	if err != nil {
+		return false, f.handleError(err)
+	}
+
+	f.write(fieldChange{
+		target: v,
+		old:    reflect.ValueOf(source),
+		new:    reflect.ValueOf(rendered),
+	})
+
+	return !bytes.Equal(source, rendered), nil
+}
+
+// visitBytes passes source to the Visitor of the fieldProcessor.  Markdown
+// is rendered straight from bytes, without converting it to a string.
+func (f *fieldProcessor) visitBytes(source []byte) ([]byte, error) {
+	if f.rendersMarkdown() {
+		return f.render(source)
+	}
+
+	value, err := f.visitor.Visit(f.field, string(source))
+	return []byte(value), err
+}
+
+// valueString returns the string held by v, a string or byte slice.
+func valueString(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		return string(v.Bytes())
+	}
+	return v.String()
+}
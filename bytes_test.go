@@ -0,0 +1,105 @@
+package markstruct
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Blob []byte
+
+func TestConvertBytes(t *testing.T) {
+	type Document struct {
+		Body     []byte          `markdown:"on"`
+		Blob     Blob            `markdown:"on"`
+		Pages    [][]byte        `markdown:"on"`
+		Raw      json.RawMessage `markdown:"on"`
+		Empty    []byte          `markdown:"on"`
+		Untagged []byte
+	}
+
+	test := &Document{
+		Body:     []byte("# Title\n\n*body*"),
+		Blob:     Blob("*blob*"),
+		Pages:    [][]byte{[]byte("*one*"), []byte("*two*")},
+		Raw:      json.RawMessage(`{"a":"*b*"}`),
+		Untagged: []byte("*untagged*"),
+	}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<h1>Title</h1>\n<p><em>body</em></p>\n", string(test.Body))
+	assert.Equal(t, Blob("<p><em>blob</em></p>\n"), test.Blob)
+	assert.Equal(t, [][]byte{[]byte("<p><em>one</em></p>\n"), []byte("<p><em>two</em></p>\n")}, test.Pages)
+	assert.Equal(t, json.RawMessage(`{"a":"*b*"}`), test.Raw)
+	assert.Nil(t, test.Empty)
+	assert.Equal(t, "*untagged*", string(test.Untagged))
+}
+
+func TestConvertAllFieldsBytes(t *testing.T) {
+	type Document struct {
+		Hash []byte
+		Addr net.IP
+		Body string
+		Text []byte `markdown:"on"`
+	}
+
+	test := &Document{
+		Hash: []byte{0xde, 0xad, 0xbe, 0xef},
+		Addr: net.ParseIP("127.0.0.1"),
+		Body: "*body*",
+		Text: []byte("*text*"),
+	}
+
+	changed, err := ConvertAllFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, test.Hash)
+	assert.Equal(t, net.ParseIP("127.0.0.1"), test.Addr)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.Body)
+	assert.Equal(t, "<p><em>text</em></p>\n", string(test.Text))
+}
+
+func TestPreviewAndSnapshotBytes(t *testing.T) {
+	type Document struct {
+		Body []byte `markdown:"on"`
+	}
+
+	test := &Document{Body: []byte("*body*")}
+
+	previews, err := Preview(test)
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldPreview{{Path: "Body", Source: "*body*", HTML: "<p><em>body</em></p>\n"}}, previews)
+
+	snap, err := ConvertFieldsSnapshot(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>body</em></p>\n", string(test.Body))
+
+	original, ok := snap.Original("Body")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("*body*"), original)
+
+	snap.Restore()
+	assert.Equal(t, "*body*", string(test.Body))
+}
+
+func TestTransformBytes(t *testing.T) {
+	type Document struct {
+		Body []byte `markdown:"on"`
+	}
+
+	test := &Document{Body: []byte("  body  ")}
+
+	changed, err := TransformFields(test, func(field FieldInfo, value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "body", string(test.Body))
+}
@@ -0,0 +1,240 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// DenyAction determines what happens to Markdown constructs that a field
+// forbids with the `deny` tag option.
+type DenyAction int
+
+const (
+	// DenyError fails conversion and validation of a field containing a
+	// forbidden construct with a *DeniedNodeError.
+	DenyError DenyAction = iota
+
+	// DenyStrip removes forbidden constructs, along with their content.
+	DenyStrip
+
+	// DenyText replaces forbidden constructs with their plain text content.
+	DenyText
+)
+
+var (
+	// ErrDeniedNode signifies that a field contains a Markdown construct
+	// forbidden by its `deny` tag option.
+	ErrDeniedNode = errors.New("denied markdown construct")
+
+	// ErrUnknownNodeKind signifies that a field's `deny` tag option names a
+	// Markdown construct that is neither a `goldmark` node kind nor one of
+	// the aliases of the option.
+	ErrUnknownNodeKind = errors.New("unknown markdown construct")
+)
+
+// DeniedNodeError describes a Markdown construct forbidden by a field's
+// `deny` tag option, and where it occurs in the field's source.
+type DeniedNodeError struct {
+	// Kind is the name of the forbidden construct, e.g. "heading".
+	Kind string
+
+	// Line and Column locate the construct in the field's source, counting
+	// from 1.  Column counts bytes.
+	Line   int
+	Column int
+}
+
+func (e *DeniedNodeError) Error() string {
+	return fmt.Sprintf("%s: %s at line %d, column %d", ErrDeniedNode, e.Kind, e.Line, e.Column)
+}
+
+// Is reports whether target is ErrDeniedNode.
+func (e *DeniedNodeError) Is(target error) bool {
+	return target == ErrDeniedNode
+}
+
+// denyAliases maps names usable with the `deny` tag option to the lowercased
+// names of the `goldmark` node kinds they cover.  Other names are matched
+// directly against node kind names, e.g. "emphasis" or "strikethrough".
+var denyAliases = map[string][]string{
+	"code":      {"codeblock", "fencedcodeblock"},
+	"codeblock": {"codeblock", "fencedcodeblock"},
+	"html":      {"htmlblock", "rawhtml"},
+	"link":      {"link", "autolink"},
+	"quote":     {"blockquote"},
+}
+
+// nodeKinds holds the lowercased names of the node kinds of `goldmark` and
+// its built-in extensions, which may be named by the `deny` tag option.
+var nodeKinds = map[string]bool{
+	"document":              true,
+	"textblock":             true,
+	"paragraph":             true,
+	"heading":               true,
+	"thematicbreak":         true,
+	"codeblock":             true,
+	"fencedcodeblock":       true,
+	"blockquote":            true,
+	"list":                  true,
+	"listitem":              true,
+	"htmlblock":             true,
+	"text":                  true,
+	"string":                true,
+	"codespan":              true,
+	"emphasis":              true,
+	"link":                  true,
+	"image":                 true,
+	"autolink":              true,
+	"rawhtml":               true,
+	"definitionlist":        true,
+	"definitionterm":        true,
+	"definitiondescription": true,
+	"table":                 true,
+	"tablerow":              true,
+	"tableheader":           true,
+	"tablecell":             true,
+	"taskcheckbox":          true,
+	"footnotelink":          true,
+	"footnotebacklink":      true,
+	"footnote":              true,
+	"footnotelist":          true,
+	"strikethrough":         true,
+}
+
+// parseDenyList parses the value of the `deny` tag option, a list of names
+// separated by '|', into the sorted node kind names it forbids.
+func parseDenyList(value string) []string {
+	denied := map[string]bool{}
+
+	for _, name := range strings.Split(value, "|") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if kinds, ok := denyAliases[name]; ok {
+			for _, kind := range kinds {
+				denied[kind] = true
+			}
+			continue
+		}
+
+		denied[name] = true
+	}
+
+	kinds := make([]string, 0, len(denied))
+	for kind := range denied {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// filterDeniedNodes applies action to every node of doc whose kind is
+// denied.  Denying an unknown kind fails with ErrUnknownNodeKind, so that a
+// misspelled name doesn't silently allow the construct.
+func filterDeniedNodes(doc ast.Node, source []byte, deny []string, action DenyAction) error {
+	denied := map[string]bool{}
+	for _, kind := range deny {
+		if !nodeKinds[kind] {
+			return fmt.Errorf("%w: %q", ErrUnknownNodeKind, kind)
+		}
+		denied[kind] = true
+	}
+
+	var found []ast.Node
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || !denied[strings.ToLower(n.Kind().String())] {
+			return ast.WalkContinue, nil
+		}
+
+		if action == DenyError {
+			line, column := nodePosition(n, source)
+			return ast.WalkStop, &DeniedNodeError{
+				Kind:   strings.ToLower(n.Kind().String()),
+				Line:   line,
+				Column: column,
+			}
+		}
+
+		found = append(found, n)
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, n := range found {
+		switch action {
+		case DenyStrip:
+			n.Parent().RemoveChild(n.Parent(), n)
+		case DenyText:
+			replaceWithText(n, source)
+		}
+	}
+
+	return nil
+}
+
+// replaceWithText replaces n with its plain text content.  Inline content of
+// n is kept, so that an image is replaced by its alt text, and a heading by
+// a paragraph.
+func replaceWithText(n ast.Node, source []byte) {
+	parent := n.Parent()
+
+	if n.Type() == ast.TypeInline {
+		if !n.HasChildren() {
+			parent.InsertBefore(parent, n, ast.NewString(nodeText(n, source)))
+		}
+
+		for c := n.FirstChild(); c != nil; c = n.FirstChild() {
+			parent.InsertBefore(parent, n, c)
+		}
+
+		parent.RemoveChild(parent, n)
+		return
+	}
+
+	p := ast.NewParagraph()
+
+	if c := n.FirstChild(); c != nil && c.Type() == ast.TypeInline {
+		for ; c != nil; c = n.FirstChild() {
+			p.AppendChild(p, c)
+		}
+	} else {
+		p.AppendChild(p, ast.NewString(blockText(n, source)))
+	}
+
+	parent.ReplaceChild(parent, n, p)
+}
+
+// blockText returns the plain text of block n.  The text of blocks nested in
+// n is separated by newlines, except for the cells of table rows, which are
+// separated by spaces.
+func blockText(n ast.Node, source []byte) []byte {
+	c := n.FirstChild()
+	if c == nil {
+		return nodeText(n, source)
+	}
+	if c.Type() == ast.TypeInline {
+		return n.Text(source)
+	}
+
+	sep := []byte("\n")
+	if n.Kind() == east.KindTableRow || n.Kind() == east.KindTableHeader {
+		sep = []byte(" ")
+	}
+
+	var parts [][]byte
+	for ; c != nil; c = c.NextSibling() {
+		parts = append(parts, blockText(c, source))
+	}
+	return bytes.Join(parts, sep)
+}
@@ -0,0 +1,127 @@
+package markstruct
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+type Review struct {
+	Title string `markdown:"on,deny=heading|image|code"`
+	Body  string `markdown:"on"`
+}
+
+func TestDenyError(t *testing.T) {
+	review := &Review{
+		Title: "Great *product*\n\n## Five stars",
+		Body:  "## Details",
+	}
+
+	changed, err := ValidateFields(review)
+	assert.False(t, changed)
+	assert.True(t, errors.Is(err, ErrDeniedNode))
+
+	var denied *DeniedNodeError
+	assert.True(t, errors.As(err, &denied))
+	assert.Equal(t, "heading", denied.Kind)
+	assert.Equal(t, 3, denied.Line)
+	assert.Equal(t, 1, denied.Column)
+	assert.Contains(t, err.Error(), "heading at line 3, column 1")
+
+	changed, err = ConvertFields(review)
+	assert.False(t, changed)
+	assert.True(t, errors.Is(err, ErrDeniedNode))
+	assert.Equal(t, "Great *product*\n\n## Five stars", review.Title)
+
+	review.Title = "Great *product*"
+
+	changed, err = ConvertFields(review)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>Great <em>product</em></p>\n", review.Title)
+	assert.Equal(t, "<h2>Details</h2>\n", review.Body)
+}
+
+func TestDenyInlineError(t *testing.T) {
+	review := &Review{
+		Title: "Look\nhere ![cat](cat.png)",
+	}
+
+	_, err := ValidateFields(review)
+
+	var denied *DeniedNodeError
+	assert.True(t, errors.As(err, &denied))
+	assert.Equal(t, "image", denied.Kind)
+	assert.Equal(t, 2, denied.Line)
+	assert.Equal(t, 6, denied.Column)
+}
+
+func TestDenyStrip(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithDenyAction(DenyStrip))
+
+	review := &Review{
+		Title: "# Wow\n\nNice ![cat](cat.png) *toy*\n\n    code()\n",
+	}
+
+	changed, err := conv.ConvertFields(review)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>Nice  <em>toy</em></p>\n", review.Title)
+}
+
+func TestDenyText(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithDenyAction(DenyText))
+
+	review := &Review{
+		Title: "# *Wow*\n\nNice ![cat](cat.png)\n\n```\na < b\n```\n",
+	}
+
+	changed, err := conv.ConvertFields(review)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>Wow</em></p>\n<p>Nice cat</p>\n<p>a &lt; b</p>\n", review.Title)
+}
+
+func TestDenyTextBlocks(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(goldmark.WithExtensions(extension.Table)), WithDenyAction(DenyText))
+
+	test := &struct {
+		Body string `markdown:"on,deny=table|list|quote"`
+	}{
+		Body: "| Name | Age |\n| --- | --- |\n| Bob | 30 |\n\n- one\n- *two*\n\n> a\n>\n> b\n",
+	}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>Name Age\nBob 30</p>\n<p>one\ntwo</p>\n<p>a\nb</p>\n", test.Body)
+}
+
+func TestDenyUnknownKind(t *testing.T) {
+	test := &struct {
+		Body string `markdown:"on,deny=table|code|headng"`
+	}{
+		Body: "# Title",
+	}
+
+	_, err := ValidateFields(test)
+	assert.True(t, errors.Is(err, ErrUnknownNodeKind))
+	assert.Equal(t, `Body: unknown markdown construct: "headng"`, err.Error())
+
+	changed, err := ConvertFields(test)
+	assert.False(t, changed)
+	assert.True(t, errors.Is(err, ErrUnknownNodeKind))
+	assert.Equal(t, "# Title", test.Body)
+}
+
+func TestParseDenyList(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"codeblock", "fencedcodeblock", "heading", "table"},
+		parseDenyList(" Heading|code||table"),
+	)
+}
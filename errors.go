@@ -0,0 +1,103 @@
+package markstruct
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrPanic signifies that rendering a field panicked, such as within a
+	// faulty `goldmark` extension.  The panic is reported by a *FieldError.
+	ErrPanic = errors.New("panic rendering field")
+)
+
+// ErrorPolicy determines how a FieldConverter handles an error rendering a
+// field.
+type ErrorPolicy int
+
+const (
+	// FailFast stops conversion at the first field that fails to render,
+	// returning its *FieldError.  This is the default.
+	FailFast ErrorPolicy = iota
+
+	// ContinueOnError renders every field possible, leaving fields that
+	// fail to render unchanged, and returns FieldErrors listing each
+	// failure.
+	ContinueOnError
+
+	// SkipOnError renders every field possible, silently leaving fields
+	// that fail to render unchanged.
+	SkipOnError
+)
+
+// FieldError is an error encountered rendering a field.
+type FieldError struct {
+	// Path locates the field within the struct, e.g. "Details.Description"
+	// or "Tags[2]".
+	Path string
+
+	Err error
+
+	// Panic and Stack hold the value passed to panic, and the stack trace
+	// of the panicking goroutine, if rendering the field panicked.
+	Panic interface{}
+	Stack []byte
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors lists the errors collected by a FieldConverter using the
+// ContinueOnError policy.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the collected errors matches target, so that
+// errors.Is can match them.
+func (e FieldErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the collected errors matching target, so that
+// errors.As can match them.
+func (e FieldErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// panicError carries a panic recovered while rendering a field.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrPanic, e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	return ErrPanic
+}
@@ -0,0 +1,178 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+)
+
+// FussyMarkdown fails to convert any source containing "BOOM".
+type FussyMarkdown struct {
+	goldmark.Markdown
+}
+
+func (f *FussyMarkdown) Convert(source []byte, w io.Writer, opts ...parser.ParseOption) error {
+	if bytes.Contains(source, []byte("BOOM")) {
+		return errors.New("BOOM")
+	}
+	return f.Markdown.Convert(source, w, opts...)
+}
+
+type BulkImport struct {
+	Title string            `markdown:"on"`
+	Tags  []string          `markdown:"on"`
+	Notes map[string]string `markdown:"on"`
+	Body  string            `markdown:"on"`
+}
+
+func newBulkImport() *BulkImport {
+	return &BulkImport{
+		Title: "BOOM *title*",
+		Tags:  []string{"*a*", "BOOM", "*c*"},
+		Notes: map[string]string{"x": "BOOM", "y": "*y*", "z": "*z*"},
+		Body:  "*body*",
+	}
+}
+
+func TestErrorPolicyFailFast(t *testing.T) {
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()})
+
+	test := newBulkImport()
+	test.Title = "*title*"
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Tags[1]", ferr.Path)
+	assert.Equal(t, "Tags[1]: BOOM", err.Error())
+
+	assert.Equal(t, "<p><em>title</em></p>\n", test.Title)
+	assert.Equal(t, []string{"<p><em>a</em></p>\n", "BOOM", "*c*"}, test.Tags)
+	assert.Equal(t, "*body*", test.Body)
+
+	test = newBulkImport()
+	test.Title = "*title*"
+	test.Tags = nil
+
+	_, err = conv.ConvertFields(test)
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Notes[x]", ferr.Path)
+	assert.Equal(t, "*body*", test.Body)
+}
+
+func TestErrorPolicyContinueOnError(t *testing.T) {
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithErrorPolicy(ContinueOnError))
+
+	test := newBulkImport()
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+
+	var errs FieldErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "Title: BOOM; Tags[1]: BOOM; Notes[x]: BOOM", err.Error())
+
+	assert.Equal(t, "BOOM *title*", test.Title)
+	assert.Equal(t, []string{"<p><em>a</em></p>\n", "BOOM", "<p><em>c</em></p>\n"}, test.Tags)
+	assert.Equal(t, map[string]string{"x": "BOOM", "y": "<p><em>y</em></p>\n", "z": "<p><em>z</em></p>\n"}, test.Notes)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.Body)
+
+	unknown := &struct {
+		Body string `markdown:"on,sanitize=missing"`
+	}{"*x*"}
+
+	_, err = conv.ConvertFields(unknown)
+	assert.True(t, errors.Is(err, ErrUnknownPolicy))
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Body", ferr.Path)
+	assert.False(t, errors.Is(err, ErrPanic))
+
+	atomic := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithErrorPolicy(ContinueOnError), WithAtomic(true))
+
+	test = newBulkImport()
+
+	changed, err = atomic.ConvertFields(test)
+	assert.False(t, changed)
+	assert.Error(t, err)
+	assert.Equal(t, newBulkImport(), test)
+}
+
+func TestErrorPolicySkipOnError(t *testing.T) {
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithErrorPolicy(SkipOnError))
+
+	test := newBulkImport()
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "BOOM *title*", test.Title)
+	assert.Equal(t, []string{"<p><em>a</em></p>\n", "BOOM", "<p><em>c</em></p>\n"}, test.Tags)
+	assert.Equal(t, map[string]string{"x": "BOOM", "y": "<p><em>y</em></p>\n", "z": "<p><em>z</em></p>\n"}, test.Notes)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.Body)
+}
+
+// PanickyMarkdown panics converting any source containing "PANIC".
+type PanickyMarkdown struct {
+	goldmark.Markdown
+}
+
+func (p *PanickyMarkdown) Convert(source []byte, w io.Writer, opts ...parser.ParseOption) error {
+	if bytes.Contains(source, []byte("PANIC")) {
+		panic("extension exploded")
+	}
+	return p.Markdown.Convert(source, w, opts...)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	conv := WithMarkdown(&PanickyMarkdown{goldmark.New()})
+
+	test := &BulkImport{
+		Title: "*title*",
+		Tags:  []string{"PANIC"},
+		Body:  "*body*",
+	}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.True(t, errors.Is(err, ErrPanic))
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Tags[0]", ferr.Path)
+	assert.Equal(t, "extension exploded", ferr.Panic)
+	assert.Contains(t, string(ferr.Stack), "PanickyMarkdown")
+	assert.Equal(t, "Tags[0]: panic rendering field: extension exploded", err.Error())
+
+	assert.Equal(t, "<p><em>title</em></p>\n", test.Title)
+	assert.Equal(t, []string{"PANIC"}, test.Tags)
+
+	collecting := WithMarkdown(&PanickyMarkdown{goldmark.New()}, WithErrorPolicy(ContinueOnError))
+
+	test.Body = "*body*"
+
+	_, err = collecting.ConvertFields(test)
+	assert.True(t, errors.Is(err, ErrPanic))
+	assert.Equal(t, "<p><em>body</em></p>\n", test.Body)
+}
+
+func TestPanicRecoveryDisabled(t *testing.T) {
+	conv := WithMarkdown(&PanickyMarkdown{goldmark.New()}, WithPanicRecovery(false))
+
+	test := &MyAnnotatedEnabledStruct{Comment: "PANIC"}
+
+	assert.PanicsWithValue(t, "extension exploded", func() {
+		_, _ = conv.ConvertFields(test)
+	})
+}
@@ -0,0 +1,121 @@
+package markstruct
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldInfo describes the struct field being converted, as passed to render
+// hooks.  For slice elements and map entries, FieldInfo describes the
+// struct field holding the slice or map.
+type FieldInfo struct {
+	// Path locates the value within the struct being converted, e.g.
+	// "Details.Description" or "Tags[2]".
+	Path string
+
+	// Field is the struct field being converted.
+	Field reflect.StructField
+
+	// Options holds the options parsed from the field's `markdown` tag.
+	Options TagOptions
+
+	// Parent is the struct value holding Field.
+	Parent reflect.Value
+}
+
+// TagOptions holds the settings parsed from a field's `markdown` struct tag,
+// such as `markdown:"on,trusted,sanitize=strict"`.
+type TagOptions struct {
+	// Enabled reports whether the field is tagged for conversion.
+	Enabled bool
+
+	// Trusted is set by the `trusted` option.
+	Trusted bool
+
+	// Sanitize holds the policy named by the `sanitize` option.
+	Sanitize string
+
+	// Pipeline holds the name of the Pipeline selected by the `pipeline`
+	// option.
+	Pipeline string
+
+	// Source holds the name of the field whose Markdown is rendered into
+	// this one, set by the `source` option.  The source field is never
+	// converted itself.  Walk ignores the option, visiting both fields as
+	// ordinary fields.
+	Source string
+
+	// Deny holds the lowercased names of the `goldmark` node kinds
+	// forbidden by the `deny` option, e.g. "heading".
+	Deny []string
+
+	// Extra holds options not recognized by markstruct, keyed by their
+	// lowercased name, for use by render hooks.  Options without a value
+	// map to the empty string.
+	Extra map[string]string
+}
+
+// BeforeRenderFunc is called with the Markdown of each field before it is
+// rendered.  It returns the Markdown to render in its place, and whether to
+// skip rendering the field, leaving it unchanged.  Returning an error fails
+// the field.
+type BeforeRenderFunc func(field FieldInfo, source []byte) ([]byte, bool, error)
+
+// AfterRenderFunc is called with the HTML rendered for each field, and
+// returns the HTML to store in its place.  Returning an error fails the
+// field.
+type AfterRenderFunc func(field FieldInfo, html []byte) ([]byte, error)
+
+func isEnabledValue(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "yes", "1", "y", "enable":
+		return true
+	}
+	return false
+}
+
+// parseTag parses a `markdown` struct tag of the form "on,key=value,...".
+func parseTag(tag reflect.StructTag) TagOptions {
+	return parseTagValue(tag.Get(structTagKey))
+}
+
+func parseTagValue(value string) TagOptions {
+	parts := strings.Split(value, ",")
+
+	opts := TagOptions{
+		Enabled: isEnabledValue(parts[0]),
+	}
+
+	for _, part := range parts[1:] {
+		key, value := splitTagOption(part)
+		switch key {
+		case "":
+			// empty option, such as from a trailing comma
+		case "sanitize":
+			opts.Sanitize = value
+		case "trusted":
+			opts.Trusted = true
+		case "pipeline":
+			opts.Pipeline = value
+		case "source":
+			opts.Source = value
+		case "deny":
+			opts.Deny = parseDenyList(value)
+		default:
+			if opts.Extra == nil {
+				opts.Extra = map[string]string{}
+			}
+			opts.Extra[key] = value
+		}
+	}
+
+	return opts
+}
+
+func splitTagOption(option string) (string, string) {
+	option = strings.TrimSpace(option)
+	if idx := strings.IndexByte(option, '='); idx >= 0 {
+		return strings.ToLower(option[:idx]), strings.TrimSpace(option[idx+1:])
+	}
+	return strings.ToLower(option), ""
+}
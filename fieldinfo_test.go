@@ -0,0 +1,170 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestParseTag(t *testing.T) {
+	type Test struct {
+		Plain   string
+		Off     string `markdown:"off"`
+		Full    string `markdown:"on,trusted,sanitize=strict,deny=image|heading"`
+		Custom  string `markdown:"yes, Track , draft=Draft,"`
+		Unknown string `markdown:",trusted"`
+	}
+
+	typ := reflect.TypeOf(Test{})
+
+	assert.Equal(t, TagOptions{}, parseTag(typ.Field(0).Tag))
+	assert.Equal(t, TagOptions{}, parseTag(typ.Field(1).Tag))
+
+	assert.Equal(
+		t,
+		TagOptions{
+			Enabled:  true,
+			Trusted:  true,
+			Sanitize: "strict",
+			Deny:     []string{"heading", "image"},
+		},
+		parseTag(typ.Field(2).Tag),
+	)
+
+	assert.Equal(
+		t,
+		TagOptions{
+			Enabled: true,
+			Extra:   map[string]string{"track": "", "draft": "Draft"},
+		},
+		parseTag(typ.Field(3).Tag),
+	)
+
+	assert.Equal(t, TagOptions{Trusted: true}, parseTag(typ.Field(4).Tag))
+}
+
+func TestRenderHooks(t *testing.T) {
+	type Post struct {
+		Draft bool
+		Title string   `markdown:"on,track"`
+		Body  string   `markdown:"on,draft=Draft"`
+		Tags  []string `markdown:"on"`
+	}
+
+	var seen []FieldInfo
+
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithBeforeRender(func(field FieldInfo, src []byte) ([]byte, bool, error) {
+			seen = append(seen, field)
+
+			if name, ok := field.Options.Extra["draft"]; ok {
+				if field.Parent.FieldByName(name).Bool() {
+					return src, true, nil
+				}
+			}
+
+			return bytes.ReplaceAll(src, []byte(":)"), []byte("☺")), false, nil
+		}),
+		WithAfterRender(func(field FieldInfo, html []byte) ([]byte, error) {
+			if _, ok := field.Options.Extra["track"]; ok {
+				html = append([]byte("<!-- tracked -->"), html...)
+			}
+			return html, nil
+		}),
+		WithAfterRender(func(field FieldInfo, html []byte) ([]byte, error) {
+			return bytes.TrimSpace(html), nil
+		}),
+	)
+
+	post := &Post{
+		Draft: true,
+		Title: "Hi :)",
+		Body:  "*draft*",
+		Tags:  []string{"_go_"},
+	}
+
+	changed, err := conv.ConvertFields(post)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<!-- tracked --><p>Hi ☺</p>", post.Title)
+	assert.Equal(t, "*draft*", post.Body)
+	assert.Equal(t, []string{"<p><em>go</em></p>"}, post.Tags)
+
+	assert.Len(t, seen, 3)
+	assert.Equal(t, "Title", seen[0].Path)
+	assert.Equal(t, "Body", seen[1].Path)
+	assert.Equal(t, "Tags[0]", seen[2].Path)
+	assert.Equal(t, "Tags", seen[2].Field.Name)
+	assert.Equal(t, reflect.TypeOf(Post{}), seen[2].Parent.Type())
+}
+
+func TestRenderHookSkip(t *testing.T) {
+	type Post struct {
+		A     string            `markdown:"on"`
+		B     []byte            `markdown:"on"`
+		C     map[string]string `markdown:"on"`
+		D     string
+		DHTML string `markdown:"on,source=D"`
+	}
+
+	obs := &RecordingObserver{}
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithObserver(obs),
+		WithBeforeRender(func(field FieldInfo, src []byte) ([]byte, bool, error) {
+			return []byte("changed"), true, nil
+		}),
+	)
+
+	post := &Post{A: "*a*", B: []byte("*b*"), C: map[string]string{"c": "*c*"}, D: "*d*", DHTML: "old"}
+
+	changed, err := conv.ConvertFields(post)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, &Post{A: "*a*", B: []byte("*b*"), C: map[string]string{"c": "*c*"}, D: "*d*", DHTML: "old"}, post)
+	assert.Empty(t, obs.Stats)
+
+	snap, err := conv.ConvertFieldsSnapshot(post)
+	assert.NoError(t, err)
+	assert.Empty(t, snap.Paths())
+
+	html, err := conv.HTML("<b>*a*</b>")
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML("&lt;b&gt;*a*&lt;/b&gt;"), html)
+}
+
+func TestRenderHookErrors(t *testing.T) {
+	boom := errors.New("boom")
+
+	before := WithMarkdown(
+		goldmark.New(),
+		WithBeforeRender(func(FieldInfo, []byte) ([]byte, bool, error) {
+			return nil, false, boom
+		}),
+	)
+
+	after := WithMarkdown(
+		goldmark.New(),
+		WithAfterRender(func(FieldInfo, []byte) ([]byte, error) {
+			return nil, boom
+		}),
+	)
+
+	for _, conv := range []FieldConverter{before, after} {
+		doc := &MyAnnotatedEnabledStruct{Comment: "*x*"}
+
+		changed, err := conv.ConvertFields(doc)
+		assert.False(t, changed)
+		assert.True(t, errors.Is(err, boom))
+		assert.Equal(t, "Comment: boom", err.Error())
+		assert.Equal(t, "*x*", doc.Comment)
+	}
+}
@@ -0,0 +1,98 @@
+package markstruct
+
+import (
+	htmltemplate "html/template"
+	"reflect"
+	"strings"
+	texttemplate "text/template"
+)
+
+// FuncMap returns functions rendering Markdown with c, for use by
+// `html/template` templates:
+//
+//   - markdown renders a string to HTML, e.g. {{ .Body | markdown }}
+//   - markdownInline renders a string to HTML without its enclosing
+//     paragraph, for use within other elements, e.g. in headings
+//   - markdownFields renders the tagged fields of a struct, returning a map
+//     of HTML keyed by field path, e.g. {{ (markdownFields .).Body }}
+//
+// Rendered HTML is returned as template.HTML, so templates don't escape it.
+// Markdown is rendered on every call; structs passed to markdownFields are
+// not modified.
+func FuncMap(c Converter) htmltemplate.FuncMap {
+	return htmltemplate.FuncMap{
+		"markdown": func(markdown string) (htmltemplate.HTML, error) {
+			return c.HTML(markdown)
+		},
+		"markdownInline": func(markdown string) (htmltemplate.HTML, error) {
+			html, err := c.HTML(markdown)
+			return htmltemplate.HTML(inlineHTML(string(html))), err
+		},
+		"markdownFields": func(s interface{}) (map[string]htmltemplate.HTML, error) {
+			previews, err := previewValue(c, s)
+			if err != nil {
+				return nil, err
+			}
+
+			fields := make(map[string]htmltemplate.HTML, len(previews))
+			for _, p := range previews {
+				fields[p.Path] = htmltemplate.HTML(p.HTML)
+			}
+			return fields, nil
+		},
+	}
+}
+
+// TextFuncMap returns the functions of FuncMap for use by `text/template`
+// templates, returning HTML as plain strings.
+func TextFuncMap(c Converter) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"markdown": func(markdown string) (string, error) {
+			html, err := c.HTML(markdown)
+			return string(html), err
+		},
+		"markdownInline": func(markdown string) (string, error) {
+			html, err := c.HTML(markdown)
+			return inlineHTML(string(html)), err
+		},
+		"markdownFields": func(s interface{}) (map[string]string, error) {
+			previews, err := previewValue(c, s)
+			if err != nil {
+				return nil, err
+			}
+
+			fields := make(map[string]string, len(previews))
+			for _, p := range previews {
+				fields[p.Path] = p.HTML
+			}
+			return fields, nil
+		},
+	}
+}
+
+// inlineHTML strips the paragraph enclosing html, if it consists of a single
+// paragraph.
+func inlineHTML(html string) string {
+	trimmed := strings.TrimSpace(html)
+	if !strings.HasPrefix(trimmed, "<p>") || !strings.HasSuffix(trimmed, "</p>") {
+		return html
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(trimmed, "<p>"), "</p>")
+	if strings.Contains(inner, "<p>") {
+		return html
+	}
+	return inner
+}
+
+// previewValue previews s with c, accepting structs as well as pointers to
+// them, as templates are commonly given struct values.
+func previewValue(c Converter, s interface{}) ([]FieldPreview, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Struct {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		s = ptr.Interface()
+	}
+	return c.Preview(s)
+}
@@ -0,0 +1,63 @@
+package markstruct
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestFuncMap(t *testing.T) {
+	type Post struct {
+		Title string `markdown:"on"`
+		Body  string `markdown:"on"`
+	}
+
+	tmpl := htmltemplate.Must(htmltemplate.New("post").Funcs(FuncMap(New(WithSanitizer(StrictPolicy())))).Parse(
+		`<h1>{{ .Title | markdownInline }}</h1>{{ .Body | markdown }}{{ with markdownFields . }}{{ .Body }}{{ end }}`,
+	))
+
+	test := Post{Title: "*title*", Body: "<b>body</b>"}
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, tmpl.Execute(b, test))
+	assert.Equal(t, "<h1><em>title</em></h1><p>body</p>\n<p>body</p>\n", b.String())
+	assert.Equal(t, "*title*", test.Title)
+}
+
+func TestFuncMapError(t *testing.T) {
+	tmpl := htmltemplate.Must(htmltemplate.New("post").Funcs(FuncMap(WithMarkdown(&FussyMarkdown{goldmark.New()}))).Parse(
+		`{{ markdown . }}`,
+	))
+
+	err := tmpl.Execute(&bytes.Buffer{}, "BOOM")
+	assert.ErrorContains(t, err, "BOOM")
+}
+
+func TestTextFuncMap(t *testing.T) {
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	tmpl := texttemplate.Must(texttemplate.New("post").Funcs(TextFuncMap(New())).Parse(
+		`{{ .Body | markdown }}{{ .Body | markdownInline }}{{ (markdownFields .).Body }}`,
+	))
+
+	test := &Post{Body: "*body*"}
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, tmpl.Execute(b, test))
+	assert.Equal(t, "<p><em>body</em></p>\n<em>body</em><p><em>body</em></p>\n", b.String())
+	assert.Equal(t, "*body*", test.Body)
+}
+
+func TestInlineHTML(t *testing.T) {
+	assert.Equal(t, "<em>a</em>", inlineHTML("<p><em>a</em></p>\n"))
+	assert.Equal(t, "<p>a</p>\n<p>b</p>\n", inlineHTML("<p>a</p>\n<p>b</p>\n"))
+	assert.Equal(t, "<h1>a</h1>\n", inlineHTML("<h1>a</h1>\n"))
+	assert.Equal(t, "", inlineHTML(""))
+}
@@ -1,9 +1,14 @@
 module github.com/herbygillot/markstruct
 
-go 1.16
+go 1.21
 
 require (
 	github.com/stretchr/testify v1.7.1
 	github.com/yuin/goldmark v1.4.12
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,95 @@
+package markstruct
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+
+	"github.com/yuin/goldmark/parser"
+)
+
+// HTML renders markdown to HTML trusted by `html/template`, which would
+// otherwise escape it, using the default FieldConverter.  HTML is for
+// Markdown held outside of structs, such as in template data:
+//
+//  body, err := markstruct.HTML(post.Body)
+//
+// Markdown skipped by a BeforeRenderFunc is returned escaped, as text.
+func HTML(markdown string, opts ...parser.ParseOption) (template.HTML, error) {
+	return defaultConverter.HTML(markdown, opts...)
+}
+
+func (c *converter) HTML(markdown string, opts ...parser.ParseOption) (template.HTML, error) {
+	fieldproc := makeFieldProcessor(c, opts...)
+	fieldproc.Operation = "HTML"
+
+	rendered, err := fieldproc.renderString(markdown)
+	if errors.Is(err, errSkipped) {
+		return template.HTML(template.HTMLEscapeString(markdown)), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(rendered), nil
+}
+
+// sourceFields returns the original Markdown of the string fields of struct
+// v named by the `source` tag option in options, keyed by field name.  The
+// Markdown is read before any field of v is converted, so that the result
+// doesn't depend on the order of fields.
+func sourceFields(v reflect.Value, options []TagOptions) map[string]string {
+	var sources map[string]string
+
+	for _, opts := range options {
+		if opts.Source == "" {
+			continue
+		}
+
+		source := v.FieldByName(opts.Source)
+		if !source.IsValid() || source.Kind() != reflect.String {
+			continue
+		}
+
+		if sources == nil {
+			sources = map[string]string{}
+		}
+		sources[opts.Source] = source.String()
+	}
+
+	return sources
+}
+
+// convertFromSource renders the Markdown of the string field named by the
+// `source` tag option into v, taking the field's original Markdown from
+// sources.  The source field itself is never converted.
+func (f *fieldProcessor) convertFromSource(sources map[string]string, v reflect.Value) (bool, error) {
+	name := f.field.Options.Source
+
+	source, ok := sources[name]
+	if !ok {
+		return false, f.handleError(fmt.Errorf("%w: source field %q is not a string", ErrInvalidType, name))
+	}
+
+	if v.Kind() != reflect.String {
+		return false, f.handleError(fmt.Errorf("%w: expect string field for source %q", ErrInvalidType, name))
+	}
+
+	if !isValidSettable(v) {
+		return false, nil
+	}
+
+	rendered, err := f.visitor.Visit(f.field, source)
+	if err != nil {
+		return false, f.handleError(err)
+	}
+
+	value := v.String()
+	f.write(fieldChange{
+		target: v,
+		old:    reflect.ValueOf(value),
+		new:    reflect.ValueOf(rendered),
+	})
+
+	return value != rendered, nil
+}
@@ -0,0 +1,148 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestHTML(t *testing.T) {
+	html, err := HTML("*hello*")
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML("<p><em>hello</em></p>\n"), html)
+
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithSanitizer(StrictPolicy()))
+
+	html, err = conv.HTML("<b>hi</b> *there*")
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML("<p>hi <em>there</em></p>\n"), html)
+
+	_, err = conv.HTML("BOOM")
+	assert.EqualError(t, err, "BOOM")
+}
+
+func TestTemplateHTMLField(t *testing.T) {
+	type Post struct {
+		Body template.HTML `markdown:"on"`
+	}
+
+	test := &Post{Body: "*body*"}
+	_, err := ConvertFields(test)
+	assert.NoError(t, err)
+
+	b := &bytes.Buffer{}
+	tmpl := template.Must(template.New("post").Parse(`<div>{{ .Body }}</div>`))
+	assert.NoError(t, tmpl.Execute(b, test))
+	assert.Equal(t, "<div><p><em>body</em></p>\n</div>", b.String())
+}
+
+func TestSourceTagOption(t *testing.T) {
+	type Post struct {
+		Body        string
+		BodyHTML    template.HTML `markdown:"on,source=Body"`
+		Summary     string
+		SummaryHTML string `markdown:"on,source=Summary,sanitize=strict"`
+	}
+
+	test := &Post{Body: "*body*", Summary: "<b>summary</b>"}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "*body*", test.Body)
+	assert.Equal(t, template.HTML("<p><em>body</em></p>\n"), test.BodyHTML)
+	assert.Equal(t, "<b>summary</b>", test.Summary)
+	assert.Equal(t, "<p>summary</p>\n", test.SummaryHTML)
+
+	changed, err = ConvertFields(test)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+
+	snap, err := ConvertFieldsSnapshot(&Post{Body: "*body*"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BodyHTML"}, snap.Paths())
+}
+
+func TestSourceTagOptionAllFields(t *testing.T) {
+	type Post struct {
+		Body     string
+		BodyHTML string `markdown:"on,source=Body"`
+		Title    string
+	}
+
+	type Reversed struct {
+		BodyHTML string `markdown:"on,source=Body"`
+		Body     string
+		Title    string
+	}
+
+	atomic := WithMarkdown(goldmark.New(), WithAtomic(true))
+
+	for _, conv := range []FieldConverter{defaultConverter, atomic} {
+		test := &Post{Body: "*body*", Title: "*title*"}
+
+		changed, err := conv.ConvertAllFields(test)
+		assert.True(t, changed)
+		assert.NoError(t, err)
+		assert.Equal(t, &Post{
+			Body:     "*body*",
+			BodyHTML: "<p><em>body</em></p>\n",
+			Title:    "<p><em>title</em></p>\n",
+		}, test)
+
+		reversed := &Reversed{Body: "*body*", Title: "*title*"}
+
+		changed, err = conv.ConvertAllFields(reversed)
+		assert.True(t, changed)
+		assert.NoError(t, err)
+		assert.Equal(t, &Reversed{
+			BodyHTML: "<p><em>body</em></p>\n",
+			Body:     "*body*",
+			Title:    "<p><em>title</em></p>\n",
+		}, reversed)
+	}
+}
+
+func TestSourceTagOptionWalk(t *testing.T) {
+	type Post struct {
+		Body     string
+		BodyHTML template.HTML `markdown:"on,source=Body"`
+	}
+
+	test := &Post{Body: "  <script>alert(1)</script> *x*  ", BodyHTML: "  <p>old</p>  "}
+
+	changed, err := TransformAllFields(test, func(field FieldInfo, value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, &Post{Body: "<script>alert(1)</script> *x*", BodyHTML: "<p>old</p>"}, test)
+}
+
+func TestSourceTagOptionInvalid(t *testing.T) {
+	type Missing struct {
+		HTML string `markdown:"on,source=Body"`
+	}
+
+	_, err := ConvertFields(&Missing{})
+	assert.True(t, errors.Is(err, ErrInvalidType))
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "HTML", ferr.Path)
+
+	type NotString struct {
+		Body string
+		HTML []string `markdown:"on,source=Body"`
+	}
+
+	_, err = ConvertFields(&NotString{})
+	assert.True(t, errors.Is(err, ErrInvalidType))
+}
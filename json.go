@@ -0,0 +1,97 @@
+package markstruct
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Markdown is a string of Markdown which renders as HTML when marshalled to
+// JSON, so that API responses hold HTML without an explicit call to
+// ConvertFields, while decoding requests keeps the raw Markdown.  Markdown
+// is rendered with the Converter set with SetJSONConverter, and
+// marshalled in the JSONFormat set with SetJSONFormat or WithJSONFormat.
+type Markdown string
+
+// JSONFormat determines how a Markdown value is marshalled to JSON.
+type JSONFormat int
+
+const (
+	// JSONHTML marshals Markdown as a string of rendered HTML.  This is
+	// the default.
+	JSONHTML JSONFormat = iota
+
+	// JSONSourceAndHTML marshals Markdown as an object holding both the
+	// Markdown and its HTML, e.g. {"source":"*hi*","html":"<p><em>hi</em></p>\n"}.
+	JSONSourceAndHTML
+)
+
+// markdownJSON is the JSON object of the JSONSourceAndHTML format.
+type markdownJSON struct {
+	Source string `json:"source"`
+	HTML   string `json:"html"`
+}
+
+// jsonSettings holds the settings of SetJSONConverter and SetJSONFormat.
+var jsonSettings = struct {
+	sync.RWMutex
+	converter Converter
+	format    JSONFormat
+}{
+	converter: defaultConverter,
+}
+
+// SetJSONConverter sets the Converter rendering Markdown values
+// marshalled to JSON.  By default they are rendered like ConvertFields.
+func SetJSONConverter(c Converter) {
+	jsonSettings.Lock()
+	defer jsonSettings.Unlock()
+	jsonSettings.converter = c
+}
+
+// SetJSONFormat sets the JSONFormat of Markdown values marshalled to JSON,
+// unless the Converter set with SetJSONConverter was created with
+// WithJSONFormat.
+func SetJSONFormat(format JSONFormat) {
+	jsonSettings.Lock()
+	defer jsonSettings.Unlock()
+	jsonSettings.format = format
+}
+
+// MarshalJSON renders m, and marshals it in the configured JSONFormat.
+func (m Markdown) MarshalJSON() ([]byte, error) {
+	jsonSettings.RLock()
+	c, format := jsonSettings.converter, jsonSettings.format
+	jsonSettings.RUnlock()
+
+	if conv, ok := c.(*converter); ok && conv.jsonFormat != nil {
+		format = *conv.jsonFormat
+	}
+
+	html, err := c.HTML(string(m))
+	if err != nil {
+		return nil, err
+	}
+
+	if format == JSONSourceAndHTML {
+		return json.Marshal(markdownJSON{Source: string(m), HTML: string(html)})
+	}
+	return json.Marshal(string(html))
+}
+
+// UnmarshalJSON sets m to the raw Markdown of a JSON string, or to the
+// source of an object in the JSONSourceAndHTML format.
+func (m *Markdown) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = Markdown(s)
+		return nil
+	}
+
+	var obj markdownJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	*m = Markdown(obj.Source)
+	return nil
+}
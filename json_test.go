@@ -0,0 +1,67 @@
+package markstruct
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+type Comment struct {
+	Author string   `json:"author"`
+	Body   Markdown `json:"body"`
+}
+
+func setJSON(t *testing.T, c Converter, format JSONFormat) {
+	SetJSONConverter(c)
+	SetJSONFormat(format)
+	t.Cleanup(func() {
+		SetJSONConverter(defaultConverter)
+		SetJSONFormat(JSONHTML)
+	})
+}
+
+func TestMarkdownMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Comment{Author: "*me*", Body: "*hi*"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"author":"*me*","body":"<p><em>hi</em></p>\n"}`, string(data))
+}
+
+func TestMarkdownMarshalJSONFormat(t *testing.T) {
+	setJSON(t, New(WithSanitizer(StrictPolicy())), JSONSourceAndHTML)
+
+	data, err := json.Marshal(Comment{Body: "<b>*hi*</b>"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"author":"","body":{"source":"<b>*hi*</b>","html":"<p><em>hi</em></p>\n"}}`, string(data))
+
+	// the format of the converter takes precedence
+	SetJSONConverter(New(WithJSONFormat(JSONHTML)))
+
+	data, err = json.Marshal(Comment{Body: "*hi*"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"author":"","body":"<p><em>hi</em></p>\n"}`, string(data))
+}
+
+func TestMarkdownMarshalJSONError(t *testing.T) {
+	setJSON(t, WithMarkdown(&FussyMarkdown{goldmark.New()}), JSONHTML)
+
+	_, err := json.Marshal(Comment{Body: "BOOM"})
+	assert.ErrorContains(t, err, "BOOM")
+}
+
+func TestMarkdownUnmarshalJSON(t *testing.T) {
+	var c Comment
+
+	err := json.Unmarshal([]byte(`{"author":"me","body":"*hi*"}`), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, Comment{Author: "me", Body: "*hi*"}, c)
+
+	err = json.Unmarshal([]byte(`{"body":{"source":"*there*","html":"<p><em>there</em></p>\n"}}`), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, Markdown("*there*"), c.Body)
+
+	err = json.Unmarshal([]byte(`{"body":42}`), &c)
+	assert.Error(t, err)
+}
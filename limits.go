@@ -0,0 +1,129 @@
+package markstruct
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Limits protects a FieldConverter against hostile Markdown, such as huge
+// fields or deeply nested lists and quotes.  A zero value for any limit
+// disables it.
+type Limits struct {
+	// MaxSourceBytes limits the size of the Markdown of each field.  The
+	// size is checked after BeforeRender hooks and pipeline Preprocessors,
+	// against the Markdown actually rendered.
+	MaxSourceBytes int
+
+	// MaxTotalSourceBytes limits the combined size of the Markdown of all
+	// fields converted by a single call, checked like MaxSourceBytes.
+	MaxTotalSourceBytes int
+
+	// MaxOutputBytes limits the size of the HTML rendered for each field,
+	// both as written by the Renderer and once pipeline Postprocessors,
+	// minification and AfterRender hooks have run.
+	MaxOutputBytes int
+
+	// MaxDepth limits how deeply the nodes of a field's parsed Markdown may
+	// nest.  The top-level blocks of a field, such as paragraphs, are at
+	// depth 1, and their text at depth 2.
+	MaxDepth int
+}
+
+var (
+	// ErrLimitExceeded signifies that a field exceeded one of the Limits
+	// of a FieldConverter.  The limit is described by a *LimitError.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// LimitError describes which of the Limits a field exceeded.  LimitErrors
+// are returned wrapped in a *FieldError locating the field.
+type LimitError struct {
+	// Limit is the name of the exceeded limit, e.g. "MaxSourceBytes".
+	Limit string
+
+	// Max is the value of the exceeded limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s of %d", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// checkSource checks the Markdown of a field against the source size
+// limits, counting it towards the total for the call.
+func (f *fieldProcessor) checkSource(source []byte) error {
+	limits := f.converter.limits
+
+	if limits.MaxSourceBytes > 0 && len(source) > limits.MaxSourceBytes {
+		return &LimitError{Limit: "MaxSourceBytes", Max: limits.MaxSourceBytes}
+	}
+
+	f.totalSourceBytes += len(source)
+	if limits.MaxTotalSourceBytes > 0 && f.totalSourceBytes > limits.MaxTotalSourceBytes {
+		return &LimitError{Limit: "MaxTotalSourceBytes", Max: limits.MaxTotalSourceBytes}
+	}
+
+	return nil
+}
+
+// limitOutput wraps w so that writing more than the MaxOutputBytes limit
+// fails, stopping rendering early.
+func (f *fieldProcessor) limitOutput(w io.Writer) io.Writer {
+	if max := f.converter.limits.MaxOutputBytes; max > 0 {
+		return &limitWriter{w: w, max: max}
+	}
+	return w
+}
+
+// checkOutput checks the final HTML of a field against the MaxOutputBytes
+// limit, as stages run after rendering may grow it.
+func (f *fieldProcessor) checkOutput(rendered []byte) error {
+	if max := f.converter.limits.MaxOutputBytes; max > 0 && len(rendered) > max {
+		return &LimitError{Limit: "MaxOutputBytes", Max: max}
+	}
+	return nil
+}
+
+type limitWriter struct {
+	w       io.Writer
+	max     int
+	written int
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.written+len(p) > l.max {
+		return 0, &LimitError{Limit: "MaxOutputBytes", Max: l.max}
+	}
+
+	n, err := l.w.Write(p)
+	l.written += n
+	return n, err
+}
+
+// checkDepth returns a LimitError if any node of doc is nested deeper than
+// max.
+func checkDepth(doc ast.Node, max int) error {
+	depth := 0
+
+	return ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			depth--
+			return ast.WalkContinue, nil
+		}
+
+		if depth > max {
+			return ast.WalkStop, &LimitError{Limit: "MaxDepth", Max: max}
+		}
+
+		depth++
+		return ast.WalkContinue, nil
+	})
+}
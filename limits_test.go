@@ -0,0 +1,139 @@
+package markstruct
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func limitOf(t *testing.T, err error) (string, *LimitError) {
+	t.Helper()
+
+	var ferr *FieldError
+	var lerr *LimitError
+
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.True(t, errors.As(err, &ferr))
+	assert.True(t, errors.As(err, &lerr))
+
+	return ferr.Path, lerr
+}
+
+func TestMaxSourceBytes(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxSourceBytes: 10}))
+
+	doc := &BulkImport{
+		Title: "*short*",
+		Tags:  []string{"ok", strings.Repeat("x", 11)},
+	}
+
+	changed, err := conv.ConvertFields(doc)
+	assert.True(t, changed)
+
+	path, lerr := limitOf(t, err)
+	assert.Equal(t, "Tags[1]", path)
+	assert.Equal(t, &LimitError{Limit: "MaxSourceBytes", Max: 10}, lerr)
+	assert.Equal(t, "Tags[1]: limit exceeded: MaxSourceBytes of 10", err.Error())
+	assert.Equal(t, strings.Repeat("x", 11), doc.Tags[1])
+}
+
+func TestMaxSourceBytesAfterBeforeRender(t *testing.T) {
+	expand := func(field FieldInfo, source []byte) ([]byte, bool, error) {
+		return []byte(strings.Repeat(string(source), 8)), false, nil
+	}
+
+	conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxSourceBytes: 4}), WithBeforeRender(expand))
+
+	doc := &BulkImport{Title: "*ab*"}
+
+	_, err := conv.ConvertFields(doc)
+	path, lerr := limitOf(t, err)
+	assert.Equal(t, "Title", path)
+	assert.Equal(t, "MaxSourceBytes", lerr.Limit)
+	assert.Equal(t, "*ab*", doc.Title)
+
+	conv = WithMarkdown(goldmark.New(), WithLimits(Limits{MaxTotalSourceBytes: 10}), WithBeforeRender(expand))
+
+	_, err = conv.ValidateFields(&BulkImport{Title: "*ab*"})
+	_, lerr = limitOf(t, err)
+	assert.Equal(t, "MaxTotalSourceBytes", lerr.Limit)
+}
+
+func TestMaxTotalSourceBytes(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxTotalSourceBytes: 10}))
+
+	doc := &BulkImport{
+		Title: "12345",
+		Body:  "123456",
+	}
+
+	_, err := conv.ValidateFields(doc)
+	path, lerr := limitOf(t, err)
+	assert.Equal(t, "Body", path)
+	assert.Equal(t, "MaxTotalSourceBytes", lerr.Limit)
+
+	// the total is counted per call
+	doc.Body = "12345"
+
+	_, err = conv.ValidateFields(doc)
+	assert.NoError(t, err)
+
+	_, err = conv.ValidateFields(doc)
+	assert.NoError(t, err)
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxOutputBytes: 100}))
+
+	doc := &BulkImport{
+		Title: "*fine*",
+		Body:  strings.Repeat("* item\n", 20),
+	}
+
+	_, err := conv.ConvertFields(doc)
+	path, lerr := limitOf(t, err)
+	assert.Equal(t, "Body", path)
+	assert.Equal(t, "MaxOutputBytes", lerr.Limit)
+	assert.Equal(t, "<p><em>fine</em></p>\n", doc.Title)
+	assert.Equal(t, strings.Repeat("* item\n", 20), doc.Body)
+}
+
+func TestMaxOutputBytesAfterRender(t *testing.T) {
+	grow := func(field FieldInfo, html []byte) ([]byte, error) {
+		return append(html, strings.Repeat("x", 1000)...), nil
+	}
+
+	for _, opt := range []Option{
+		WithPipeline(Pipeline{Postprocessors: []Stage{grow}}),
+		WithAfterRender(grow),
+	} {
+		conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxOutputBytes: 20}), opt)
+
+		doc := &BulkImport{Title: "*fine*"}
+
+		_, err := conv.ConvertFields(doc)
+		path, lerr := limitOf(t, err)
+		assert.Equal(t, "Title", path)
+		assert.Equal(t, "MaxOutputBytes", lerr.Limit)
+		assert.Equal(t, "*fine*", doc.Title)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithLimits(Limits{MaxDepth: 4}))
+
+	doc := &BulkImport{
+		Title: "> > quoted",
+		Body:  "> > > > > > deeply quoted",
+	}
+
+	_, err := conv.ConvertFields(doc)
+	path, lerr := limitOf(t, err)
+	assert.Equal(t, "Body", path)
+	assert.Equal(t, &LimitError{Limit: "MaxDepth", Max: 4}, lerr)
+	assert.Equal(t, "<blockquote>\n<blockquote>\n<p>quoted</p>\n</blockquote>\n</blockquote>\n", doc.Title)
+}
@@ -0,0 +1,284 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Rule checks the parsed Markdown of a field for problems.  Check is given
+// the root of the `goldmark` AST and the source it was parsed from, and
+// returns a Diagnostic for every problem found.  The Path and Rule of the
+// returned Diagnostics are filled in by the caller.
+type Rule interface {
+	Name() string
+	Check(doc ast.Node, source []byte) []Diagnostic
+}
+
+// Diagnostic describes a problem found by a Rule in the Markdown of a field.
+type Diagnostic struct {
+	// Path locates the field within the struct, e.g. "Details.Description"
+	// or "Tags[2]".
+	Path string
+
+	// Rule is the name of the Rule that reported the problem.
+	Rule string
+
+	// Line and Column locate the problem in the field's source, counting
+	// from 1.  Column counts bytes.
+	Line   int
+	Column int
+
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", d.Path, d.Line, d.Column, d.Message, d.Rule)
+}
+
+var (
+	// ErrLint signifies that validation found problems with the Markdown
+	// of one or more fields, reported by a *LintError.
+	ErrLint = errors.New("markdown lint failed")
+)
+
+// LintError is returned by ValidateFields and ValidateAllFields when rules
+// set with WithRules report problems.
+type LintError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *LintError) Error() string {
+	msgs := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		msgs[i] = d.String()
+	}
+	return fmt.Sprintf("%s: %s", ErrLint, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrLint.
+func (e *LintError) Is(target error) bool {
+	return target == ErrLint
+}
+
+// DefaultRules returns the built-in rules used by Lint when no rules are
+// given: HeadingIncrement, NoEmptyLinks, NoBareURLs, MaxLineLength(80) and
+// NoTrailingWhitespace.
+func DefaultRules() []Rule {
+	return []Rule{
+		HeadingIncrement,
+		NoEmptyLinks,
+		NoBareURLs,
+		MaxLineLength(80),
+		NoTrailingWhitespace,
+	}
+}
+
+var (
+	// HeadingIncrement reports headings that skip a level, such as an h4
+	// following an h2.
+	HeadingIncrement Rule = headingIncrementRule{}
+
+	// NoEmptyLinks reports links without a destination or without text.
+	NoEmptyLinks Rule = emptyLinksRule{}
+
+	// NoBareURLs reports URLs written as plain text rather than as links.
+	NoBareURLs Rule = bareURLsRule{}
+
+	// NoTrailingWhitespace reports lines ending in whitespace, other than
+	// the two spaces of a hard line break.
+	NoTrailingWhitespace Rule = trailingWhitespaceRule{}
+)
+
+// MaxLineLength returns a Rule that reports source lines longer than max
+// characters.
+func MaxLineLength(max int) Rule {
+	return lineLengthRule{max: max}
+}
+
+type headingIncrementRule struct{}
+
+func (headingIncrementRule) Name() string { return "heading-increment" }
+
+func (headingIncrementRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+	previous := 0
+
+	walkNodes(doc, func(n ast.Node) {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return
+		}
+
+		if previous > 0 && heading.Level > previous+1 {
+			diags = append(diags, nodeDiagnostic(n, source,
+				"heading level jumps from h%d to h%d", previous, heading.Level))
+		}
+		previous = heading.Level
+	})
+
+	return diags
+}
+
+type emptyLinksRule struct{}
+
+func (emptyLinksRule) Name() string { return "no-empty-links" }
+
+func (emptyLinksRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	walkNodes(doc, func(n ast.Node) {
+		link, ok := n.(*ast.Link)
+		if !ok {
+			return
+		}
+
+		if len(bytes.TrimSpace(link.Destination)) == 0 {
+			diags = append(diags, nodeDiagnostic(n, source, "link has no destination"))
+		}
+
+		if len(bytes.TrimSpace(link.Text(source))) == 0 {
+			diags = append(diags, nodeDiagnostic(n, source, "link has no text"))
+		}
+	})
+
+	return diags
+}
+
+var bareURLPattern = regexp.MustCompile(`(?:https?://|www\.)[^\s<>]+`)
+
+type bareURLsRule struct{}
+
+func (bareURLsRule) Name() string { return "no-bare-urls" }
+
+func (bareURLsRule) Check(doc ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	walkNodes(doc, func(n ast.Node) {
+		t, ok := n.(*ast.Text)
+		if !ok || isWithinLink(n) {
+			return
+		}
+
+		value := t.Segment.Value(source)
+		for _, loc := range bareURLPattern.FindAllIndex(value, -1) {
+			line, column := offsetPosition(source, t.Segment.Start+loc[0])
+			diags = append(diags, Diagnostic{
+				Line:    line,
+				Column:  column,
+				Message: fmt.Sprintf("bare URL %q should be a link", value[loc[0]:loc[1]]),
+			})
+		}
+	})
+
+	return diags
+}
+
+type lineLengthRule struct {
+	max int
+}
+
+func (lineLengthRule) Name() string { return "line-length" }
+
+func (r lineLengthRule) Check(_ ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	for i, line := range bytes.Split(source, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if length := utf8.RuneCount(line); length > r.max {
+			diags = append(diags, Diagnostic{
+				Line:    i + 1,
+				Column:  r.max + 1,
+				Message: fmt.Sprintf("line is %d characters long, exceeding %d", length, r.max),
+			})
+		}
+	}
+
+	return diags
+}
+
+type trailingWhitespaceRule struct{}
+
+func (trailingWhitespaceRule) Name() string { return "no-trailing-whitespace" }
+
+func (trailingWhitespaceRule) Check(_ ast.Node, source []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	for i, line := range bytes.Split(source, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		trimmed := bytes.TrimRight(line, " \t")
+
+		trailing := line[len(trimmed):]
+		if len(trailing) == 0 || len(trimmed) > 0 && string(trailing) == "  " {
+			continue
+		}
+
+		diags = append(diags, Diagnostic{
+			Line:    i + 1,
+			Column:  len(trimmed) + 1,
+			Message: "line has trailing whitespace",
+		})
+	}
+
+	return diags
+}
+
+// lint checks source against the Rules of the fieldProcessor, recording
+// any problems found against the field being converted.
+func (f *fieldProcessor) lint(source []byte) {
+	doc := f.markdown().Parser().Parse(text.NewReader(source), f.parseOptions...)
+
+	// rule packs may share rules, e.g. HeadingIncrement, which are only
+	// checked once
+	checked := map[string]bool{}
+
+	for _, rule := range f.Rules {
+		if checked[rule.Name()] {
+			continue
+		}
+		checked[rule.Name()] = true
+
+		for _, d := range rule.Check(doc, source) {
+			d.Path = f.field.Path
+			if d.Rule == "" {
+				d.Rule = rule.Name()
+			}
+			f.diagnostics = append(f.diagnostics, d)
+		}
+	}
+}
+
+// walkNodes calls fn for every node of the AST rooted at doc.
+func walkNodes(doc ast.Node, fn func(ast.Node)) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			fn(n)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func nodeDiagnostic(n ast.Node, source []byte, format string, args ...interface{}) Diagnostic {
+	line, column := nodePosition(n, source)
+	return Diagnostic{
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func isWithinLink(n ast.Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		switch p.Kind() {
+		case ast.KindLink, ast.KindAutoLink, ast.KindImage:
+			return true
+		}
+	}
+	return false
+}
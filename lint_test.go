@@ -0,0 +1,102 @@
+package markstruct
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestLintRules(t *testing.T) {
+	type Section struct {
+		Body string `markdown:"on"`
+	}
+
+	type Article struct {
+		Title    string
+		Summary  string            `markdown:"on"`
+		Sections []Section         `markdown:"on"`
+		Notes    map[string]string `markdown:"on"`
+	}
+
+	article := &Article{
+		Title:   "see https://example.com",
+		Summary: "# Intro\n\n### Details\n\nsee https://example.com ",
+		Sections: []Section{
+			{Body: "[](https://example.com) and [text]()"},
+			{Body: strings.Repeat("a", 81)},
+		},
+		Notes: map[string]string{
+			"tip": "line  \nbreak\t",
+		},
+	}
+
+	diags, err := Lint(article)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			{Path: "Summary", Rule: "heading-increment", Line: 3, Column: 1, Message: "heading level jumps from h1 to h3"},
+			{Path: "Summary", Rule: "no-bare-urls", Line: 5, Column: 5, Message: `bare URL "https://example.com" should be a link`},
+			{Path: "Summary", Rule: "no-trailing-whitespace", Line: 5, Column: 24, Message: "line has trailing whitespace"},
+			{Path: "Sections[0].Body", Rule: "no-empty-links", Line: 1, Column: 1, Message: "link has no text"},
+			{Path: "Sections[0].Body", Rule: "no-empty-links", Line: 1, Column: 29, Message: "link has no destination"},
+			{Path: "Sections[1].Body", Rule: "line-length", Line: 1, Column: 81, Message: "line is 81 characters long, exceeding 80"},
+			{Path: "Notes[tip]", Rule: "no-trailing-whitespace", Line: 2, Column: 6, Message: "line has trailing whitespace"},
+		},
+		diags,
+	)
+
+	assert.Equal(t, "# Intro\n\n### Details\n\nsee https://example.com ", article.Summary)
+	assert.Equal(t, "Notes[tip]:2:6: line has trailing whitespace (no-trailing-whitespace)", diags[6].String())
+}
+
+func TestLintSelectedRules(t *testing.T) {
+	doc := &MyAnnotatedEnabledStruct{
+		Comment: "# One\n\n### Three ",
+	}
+
+	diags, err := Lint(doc, HeadingIncrement)
+	assert.NoError(t, err)
+	assert.Len(t, diags, 1)
+	assert.Equal(t, "heading-increment", diags[0].Rule)
+
+	diags, err = Lint(doc, MaxLineLength(100))
+	assert.NoError(t, err)
+	assert.Empty(t, diags)
+
+	diags, err = Lint(MyAnnotatedEnabledStruct{})
+	assert.Nil(t, diags)
+	assert.True(t, isInvalidType(err))
+}
+
+func TestValidateFieldsWithRules(t *testing.T) {
+	conv := WithMarkdown(goldmark.New(), WithRules(NoBareURLs))
+
+	doc := &MyAnnotatedEnabledStruct{
+		Comment: "see https://example.com",
+	}
+
+	changed, err := conv.ValidateFields(doc)
+	assert.True(t, changed)
+	assert.True(t, errors.Is(err, ErrLint))
+
+	var lintErr *LintError
+	assert.True(t, errors.As(err, &lintErr))
+	assert.Len(t, lintErr.Diagnostics, 1)
+	assert.Equal(t, "Comment", lintErr.Diagnostics[0].Path)
+	assert.Equal(t, "see https://example.com", doc.Comment)
+
+	changed, err = conv.ConvertFields(doc)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	doc.Comment = "see [example](https://example.com)"
+
+	_, err = conv.ValidateFields(doc)
+	assert.NoError(t, err)
+}
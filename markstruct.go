@@ -5,8 +5,10 @@
 // then render the value of these fields as Markdown to HTML in-place. That is to
 // say the value of each field itself will be changed within the struct to be the HTML
 // result of rendering the original field's value as Markdown.  markstruct targets
-// fields whose type are string, pointer to string, string slice, and maps with
-// string values.  markstruct uses `github.com/yuin/goldmark` to render
+// fields whose type are string, pointer to string, string slice, maps with
+// string values, byte slices holding a Markdown document, and optional
+// strings such as `sql.NullString`, which are only converted when Valid.
+// markstruct uses `github.com/yuin/goldmark` to render
 // Markdown, and allows for custom goldmark.Markdown objects and parse options.
 //
 // Fields within a struct that should be converted should be annotated with the
@@ -31,17 +33,52 @@
 //
 // markstruct can optionally modify all struct string fields unequivocally,
 // ignoring the presence of this tag.
+//
+// Further options may follow "on" in the tag, separated by commas:
+//
+//  type Comment struct {
+//    Body string `markdown:"on,sanitize=strict"`
+//  }
+//
+// The `sanitize` option selects a named Sanitizer policy used to clean the
+// rendered HTML of the field (see WithPolicy).  The `trusted` option marks a
+// field as coming from a trusted author, rendering it with raw HTML passed
+// through (see WithTrustedMarkdown).  The `deny` option forbids Markdown
+// constructs within a field, such as headings or images:
+//
+//  type Review struct {
+//    Title string `markdown:"on,deny=heading|image|table|code"`
+//  }
+//
+// Forbidden constructs fail conversion by default (see WithDenyAction).  The
+// `pipeline` option selects a named Pipeline of stages run around the
+// field's rendering (see WithNamedPipeline).
+//
+// Fields of named string types, such as `template.HTML`, are converted like
+// strings.  The `source` option renders the Markdown of another string field
+// into the tagged one, leaving the source as it is, even for
+// ConvertAllFields, so that templates can use the HTML while the Markdown
+// stays a plain string:
+//
+//  type Post struct {
+//    Body     string
+//    BodyHTML template.HTML `markdown:"on,source=Body"`
+//  }
 package markstruct
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"io"
+	"html/template"
 	"reflect"
-	"strings"
+	"runtime/debug"
+	"sort"
+	"time"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
 )
 
 const (
@@ -60,26 +97,105 @@ type FieldConverter interface {
 	ValidateAllFields(s interface{}, opts ...parser.ParseOption) (bool, error)
 }
 
+// Converter is a FieldConverter which can also lint, preview and walk the
+// fields of a struct, and render Markdown strings.  WithMarkdown and New
+// return a Converter.
+type Converter interface {
+	FieldConverter
+
+	Lint(s interface{}, rules ...Rule) ([]Diagnostic, error)
+
+	Preview(s interface{}, opts ...parser.ParseOption) ([]FieldPreview, error)
+
+	HTML(markdown string, opts ...parser.ParseOption) (template.HTML, error)
+
+	ConvertFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error)
+
+	ConvertAllFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error)
+
+	Walk(s interface{}, v Visitor) (bool, error)
+
+	WalkAllFields(s interface{}, v Visitor) (bool, error)
+}
+
 type converter struct {
-	markdown goldmark.Markdown
+	markdown      goldmark.Markdown
+	trusted       goldmark.Markdown
+	sanitizer     Sanitizer
+	policies      map[string]Sanitizer
+	denyAction    DenyAction
+	rules         []Rule
+	atomic        bool
+	errPolicy     ErrorPolicy
+	recoverPanics bool
+	limits        Limits
+	beforeRender  []BeforeRenderFunc
+	afterRender   []AfterRenderFunc
+	observers     []Observer
+	renderer      Renderer
+	pipeline      Pipeline
+	pipelines     map[string]Pipeline
+	minify        bool
+	jsonFormat    *JSONFormat
 }
 
 type fieldProcessor struct {
+	Operation        string
 	ConvertAllFields bool
 	ValidateOnly     bool
+	LintOnly         bool
+	RecordChanges    bool
+	Atomic           bool
+	ErrorPolicy      ErrorPolicy
+	Rules            []Rule
 
 	converter    *converter
+	visitor      Visitor
 	parseOptions []parser.ParseOption
+	diagnostics  []Diagnostic
+	changes      []fieldChange
+	pending      []fieldChange
+	errors       FieldErrors
+
+	totalSourceBytes int
+
+	// cached is set when the Renderer reports with ReportCacheHit that the
+	// HTML of the field being rendered was taken from a cache.
+	cached bool
+
+	// field describes the struct field being converted.
+	field FieldInfo
 }
 
-var _ FieldConverter = (*converter)(nil)
+// fieldChange records a rendered value the fieldProcessor has written, or
+// would have written, in place of the original.
+type fieldChange struct {
+	path string
 
-var defaultConverter = WithMarkdown(goldmark.New())
+	// target is the settable value replaced, or the map holding it, in
+	// which case key is the map key of the value.
+	target reflect.Value
+	key    reflect.Value
+
+	old reflect.Value
+	new reflect.Value
+}
+
+var _ Converter = (*converter)(nil)
+
+var defaultConverter = WithMarkdown(
+	goldmark.New(),
+	WithTrustedMarkdown(goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))),
+)
 
 var (
 	// ErrInvalidType signifies that we have received a value of type other
 	// than the expected pointer to struct.
 	ErrInvalidType = errors.New("invalid type")
+
+	// ErrUnknownPolicy signifies that a field's `sanitize` tag option names
+	// a policy that has not been registered with the converter.
+	ErrUnknownPolicy = errors.New("unknown sanitizer policy")
 )
 
 // ConvertFields accepts a pointer to a struct, and will modify tagged
@@ -101,7 +217,10 @@ var (
 //  }
 //
 // ConvertFields supports struct fields of type string, *string, []string,
-// and maps with string values.
+// maps with string values, byte slices holding a Markdown document, and
+// optional strings such as `sql.NullString`, which are only converted when
+// Valid.  Byte slices are only converted when tagged, even by
+// ConvertAllFields.
 func ConvertFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
 	return defaultConverter.ConvertFields(s, opts...)
 }
@@ -111,7 +230,9 @@ func ConvertFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
 // ConvertFields, ValidateFields makes no changes to the struct or its
 // fields.  ValidateFields returns the same values as ConvertFields:
 // a boolean indicating whether fields would have been changed, as well
-// as any error encountered.
+// as any error encountered.  Values of types implementing MarkdownConverter,
+// or with a converter registered with RegisterTypeConverter, are not
+// converted when validating, so they are never reported as changed.
 func ValidateFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
 	return defaultConverter.ValidateFields(s, opts...)
 }
@@ -135,66 +256,271 @@ func ConvertAllFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
 // ValidateAllFields behaves like ConvertAllFields, except that it makes no
 // changes to a struct.  ValidateAllFields can be used to test for errors
 // in a situation where ConvertAllFields would be used.  ValidateAllFields
-// returns the same return values as ConvertAllFields.
+// returns the same return values as ConvertAllFields.  Like ValidateFields,
+// ValidateAllFields does not report values of types implementing
+// MarkdownConverter or with a registered type converter.
 func ValidateAllFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
 	return defaultConverter.ValidateAllFields(s, opts...)
 }
 
-// WithMarkdown creates a FieldConverter from a custom `goldmark.Markdown` object.
+// Lint accepts a pointer to a struct and checks the Markdown of its tagged
+// fields against rules, returning a Diagnostic for every problem found.  Lint
+// makes no changes to the struct.  If no rules are given, the rules set
+// with WithRules are used, or DefaultRules if there are none.  Like
+// ValidateFields, Lint returns an ErrInvalidType error if given any other
+// type besides a pointer to a struct.
+func Lint(s interface{}, rules ...Rule) ([]Diagnostic, error) {
+	return defaultConverter.Lint(s, rules...)
+}
+
+// Preview accepts a pointer to a struct, and returns a FieldPreview for every
+// tagged field, holding the field's Markdown and the HTML that ConvertFields
+// would render from it.  Preview makes no changes to the struct.  Like
+// ValidateFields, Preview has no FieldPreview for values of types
+// implementing MarkdownConverter or with a registered type converter.
+func Preview(s interface{}, opts ...parser.ParseOption) ([]FieldPreview, error) {
+	return defaultConverter.Preview(s, opts...)
+}
+
+// ConvertFieldsSnapshot converts a struct like ConvertFields, but returns a
+// Snapshot recording the original value of every field it changed, which can
+// be used to restore the struct's Markdown after conversion.  If an error is
+// encountered, the Snapshot records the changes made before it occurred.
+func ConvertFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error) {
+	return defaultConverter.ConvertFieldsSnapshot(s, opts...)
+}
+
+// ConvertAllFieldsSnapshot converts a struct like ConvertAllFields, and
+// returns a Snapshot like ConvertFieldsSnapshot.
+func ConvertAllFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error) {
+	return defaultConverter.ConvertAllFieldsSnapshot(s, opts...)
+}
+
+// WithMarkdown creates a Converter from a custom `goldmark.Markdown` object.
 // Use this with `goldmark.New` to allow using markstruct with non-default `goldmark`
-// extensions or configuration.
-func WithMarkdown(md goldmark.Markdown) FieldConverter {
-	return &converter{
-		markdown: md,
+// extensions or configuration.  WithMarkdown optionally accepts Options that
+// further configure the Converter, such as WithSanitizer.
+func WithMarkdown(md goldmark.Markdown, opts ...Option) Converter {
+	c := &converter{
+		markdown:      md,
+		recoverPanics: true,
+		policies: map[string]Sanitizer{
+			PolicyStrict: StrictPolicy(),
+			PolicyUGC:    UGCPolicy(),
+		},
 	}
+
+	c.renderer = &goldmarkRenderer{converter: c}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// New creates a Converter configured by opts, rendering Markdown with a
+// default `goldmark.Markdown` unless a Renderer is set with WithRenderer.
+func New(opts ...Option) Converter {
+	return WithMarkdown(goldmark.New(), opts...)
 }
 
 func (c *converter) ConvertFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
-	return c.process(s, false, false, opts...)
+	return c.process(s, "ConvertFields", false, false, opts...)
 }
 
 func (c *converter) ConvertAllFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
-	return c.process(s, true, false, opts...)
+	return c.process(s, "ConvertAllFields", true, false, opts...)
 }
 
 func (c *converter) ValidateFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
-	return c.process(s, false, true, opts...)
+	return c.process(s, "ValidateFields", false, true, opts...)
 }
 
 func (c *converter) ValidateAllFields(s interface{}, opts ...parser.ParseOption) (bool, error) {
-	return c.process(s, true, true, opts...)
+	return c.process(s, "ValidateAllFields", true, true, opts...)
+}
+
+func (c *converter) Lint(s interface{}, rules ...Rule) ([]Diagnostic, error) {
+	elem, err := structElem(s)
+	if err != nil || !elem.IsValid() {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		rules = c.rules
+	}
+
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	fieldproc := makeFieldProcessor(c)
+	fieldproc.Operation = "Lint"
+	fieldproc.ValidateOnly = true
+	fieldproc.LintOnly = true
+	fieldproc.Rules = rules
+
+	_, err = fieldproc.run(elem)
+	return fieldproc.diagnostics, err
+}
+
+func (c *converter) Preview(s interface{}, opts ...parser.ParseOption) ([]FieldPreview, error) {
+	elem, err := structElem(s)
+	if err != nil || !elem.IsValid() {
+		return nil, err
+	}
+
+	fieldproc := makeFieldProcessor(c, opts...)
+	fieldproc.Operation = "Preview"
+	fieldproc.ValidateOnly = true
+	fieldproc.RecordChanges = true
+	fieldproc.ErrorPolicy = c.errPolicy
+
+	if _, err := fieldproc.run(elem); err != nil {
+		return nil, err
+	}
+
+	previews := make([]FieldPreview, len(fieldproc.changes))
+	for i, change := range fieldproc.changes {
+		previews[i] = FieldPreview{
+			Path:   change.path,
+			Source: valueString(change.old),
+			HTML:   valueString(change.new),
+		}
+	}
+
+	return previews, nil
+}
+
+func (c *converter) ConvertFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error) {
+	return c.snapshot(s, "ConvertFieldsSnapshot", false, opts...)
+}
+
+func (c *converter) ConvertAllFieldsSnapshot(s interface{}, opts ...parser.ParseOption) (*Snapshot, error) {
+	return c.snapshot(s, "ConvertAllFieldsSnapshot", true, opts...)
+}
+
+func (c *converter) snapshot(s interface{}, op string, allFields bool, opts ...parser.ParseOption) (*Snapshot, error) {
+	elem, err := structElem(s)
+	if err != nil || !elem.IsValid() {
+		return &Snapshot{}, err
+	}
+
+	fieldproc := makeFieldProcessor(c, opts...)
+	fieldproc.Operation = op
+	fieldproc.ConvertAllFields = allFields
+	fieldproc.RecordChanges = true
+	fieldproc.Atomic = c.atomic
+	fieldproc.ErrorPolicy = c.errPolicy
+
+	_, err = fieldproc.run(elem)
+	return newSnapshot(fieldproc.changes), err
+}
+
+func (c *converter) process(s interface{}, op string, allFields bool, validateOnly bool, opts ...parser.ParseOption) (bool, error) {
+	elem, err := structElem(s)
+	if err != nil || !elem.IsValid() {
+		return false, err
+	}
+
+	fieldproc := makeFieldProcessor(c, opts...)
+	fieldproc.Operation = op
+	fieldproc.ConvertAllFields = allFields
+	fieldproc.ValidateOnly = validateOnly
+	fieldproc.Atomic = c.atomic
+	fieldproc.ErrorPolicy = c.errPolicy
+
+	if validateOnly {
+		fieldproc.Rules = c.rules
+	}
+
+	changed, err := fieldproc.run(elem)
+	if err == nil && len(fieldproc.diagnostics) > 0 {
+		err = &LintError{Diagnostics: fieldproc.diagnostics}
+	}
+
+	return changed, err
 }
 
-func (c *converter) process(s interface{}, allFields bool, validateOnly bool, opts ...parser.ParseOption) (bool, error) {
+// structElem returns the struct pointed to by s.  structElem returns an
+// invalid Value if s is nil or a nil pointer, and an ErrInvalidType error if
+// s is not a pointer.
+func structElem(s interface{}) (reflect.Value, error) {
 	objval := reflect.ValueOf(s)
 
 	if !objval.IsValid() {
-		return false, nil
+		return reflect.Value{}, nil
 	}
 
 	objtype := reflect.TypeOf(s)
 	if objtype.Kind() != reflect.Ptr {
-		return false, fmt.Errorf("%w: expect pointer to struct", ErrInvalidType)
+		return reflect.Value{}, fmt.Errorf("%w: expect pointer to struct", ErrInvalidType)
 	}
 
 	elem := objval.Elem()
 	if !isValidSettable(elem) {
-		return false, nil
+		return reflect.Value{}, nil
 	}
 
-	fieldproc := makeFieldProcessor(c, opts...)
-	fieldproc.ConvertAllFields = allFields
-	fieldproc.ValidateOnly = validateOnly
+	return elem, nil
+}
 
-	return fieldproc.convertStruct(elem)
+// run converts the fields of struct v.  In Atomic mode, rendered values are
+// only written once every field has rendered successfully, otherwise v is
+// left untouched.
+func (f *fieldProcessor) run(v reflect.Value) (changed bool, err error) {
+	if len(f.converter.observers) > 0 {
+		start := time.Now()
+		call := f.callStarted(v)
+		defer func() { f.callFinished(call, start, changed, err) }()
+	}
+
+	if fn := f.customConverter(v.Type()); fn != nil {
+		changed, err = f.convertCustom(fn, v, v, reflect.Value{})
+	} else {
+		changed, err = f.convertStruct(v)
+	}
+	if err == nil && len(f.errors) > 0 && f.ErrorPolicy == ContinueOnError {
+		err = f.errors
+	}
+
+	if f.Atomic && !f.ValidateOnly {
+		pending := f.pending
+		f.pending = nil
+
+		if err != nil {
+			f.changes = nil
+			return false, err
+		}
+
+		for _, change := range pending {
+			change.apply(change.new)
+		}
+	}
+
+	return changed, err
 }
 
 func (f *fieldProcessor) convert(v reflect.Value) (bool, error) {
+	if v.IsValid() {
+		if fn := f.customConverter(v.Type()); fn != nil {
+			return f.convertCustom(fn, v, v, reflect.Value{})
+		}
+
+		if field := nullStringField(v.Type()); field >= 0 {
+			return f.convertNullString(v, field)
+		}
+	}
+
 	switch v.Kind() {
 	case reflect.Ptr:
 		elem := v.Elem()
 		return f.convert(elem)
 	case reflect.Slice, reflect.Array:
+		if isBytes(v) {
+			return f.convertBytes(v)
+		}
 		return f.convertSlice(v)
 	case reflect.Map:
 		return f.convertMap(v)
@@ -212,8 +538,10 @@ func (f *fieldProcessor) convertMap(v reflect.Value) (bool, error) {
 		return false, fmt.Errorf("%w: expect map", ErrInvalidType)
 	}
 
-	// only process maps with string values
-	if v.Type().Elem().Kind() != reflect.String {
+	// only process maps with string values, or values of types with a
+	// registered converter
+	custom := f.customConverter(v.Type().Elem())
+	if v.Type().Elem().Kind() != reflect.String && custom == nil {
 		return false, nil
 	}
 
@@ -224,22 +552,43 @@ func (f *fieldProcessor) convertMap(v reflect.Value) (bool, error) {
 	var changed bool
 	var err error
 
-	for _, kval := range v.MapKeys() {
+	parentPath := f.field.Path
+	defer func() { f.field.Path = parentPath }()
+
+	for _, kval := range sortedMapKeys(v) {
 		value := v.MapIndex(kval)
+		f.field.Path = fmt.Sprintf("%s[%v]", parentPath, kval)
+
+		if custom != nil {
+			var fchanged bool
+			fchanged, err = f.convertCustom(custom, value, v, kval)
+
+			changed = fchanged || changed
+			if err != nil {
+				break
+			}
+			continue
+		}
 
 		rawstr := value.String()
-		mdstr, err := f.renderString(rawstr)
+
+		var mdstr string
+		mdstr, err = f.visitor.Visit(f.field, rawstr)
 		if err != nil {
-			break
+			if err = f.handleError(err); err != nil {
+				break
+			}
+			continue
 		}
 
-		if rawstr != mdstr {
-			if !f.ValidateOnly {
-				v.SetMapIndex(kval, reflect.ValueOf(mdstr))
-			}
+		f.write(fieldChange{
+			target: v,
+			key:    kval,
+			old:    value,
+			new:    reflect.ValueOf(mdstr),
+		})
 
-			changed = true
-		}
+		changed = rawstr != mdstr || changed
 	}
 
 	return changed, err
@@ -253,9 +602,15 @@ func (f *fieldProcessor) convertSlice(v reflect.Value) (bool, error) {
 	var changed bool
 	var err error
 
+	parentPath := f.field.Path
+	defer func() { f.field.Path = parentPath }()
+
 	for i := 0; i < v.Len(); i++ {
 		entry := v.Index(i)
-		fchanged, err := f.convert(entry)
+		f.field.Path = fmt.Sprintf("%s[%d]", parentPath, i)
+
+		var fchanged bool
+		fchanged, err = f.convert(entry)
 
 		changed = fchanged || changed
 		if err != nil {
@@ -271,20 +626,62 @@ func (f *fieldProcessor) convertStruct(v reflect.Value) (bool, error) {
 		return false, fmt.Errorf("%w: expect struct", ErrInvalidType)
 	}
 
+	if changed, ok, err := f.convertSelf(v); ok {
+		return changed, err
+	}
+
 	var changed bool
 	var err error
 
+	fields := markdownFields(v)
+
+	options := make([]TagOptions, v.NumField())
+	for i := range options {
+		options[i] = fieldOptions(v.Type(), i)
+		if fields != nil {
+			options[i] = fields[v.Type().Field(i).Name]
+		}
+	}
+
+	// the source option only applies to Markdown conversion; other
+	// Visitors see both fields as ordinary fields
+	var sources map[string]string
+	if f.rendersMarkdown() {
+		sources = sourceFields(v, options)
+	}
+
+	parent := f.field
+	defer func() { f.field = parent }()
+
 	for i := 0; i < v.NumField(); i++ {
 		fchanged := false
 		field := v.Field(i)
+		structfield := v.Type().Field(i)
+		opts := options[i]
 
-		if !isStruct(field) {
-			if !f.ConvertAllFields && !isStructFieldTagEnabled(v, i) {
+		// fields rendered into another field keep their Markdown
+		if _, ok := sources[structfield.Name]; ok {
+			continue
+		}
+
+		if !isStruct(field) || f.hasTypeConverter(structfield.Type) || isNullString(structfield.Type) {
+			if !f.ConvertAllFields && !opts.Enabled {
 				continue
 			}
 		}
 
-		fchanged, err = f.convert(field)
+		f.field = FieldInfo{
+			Path:    joinPath(parent.Path, structfield.Name),
+			Field:   structfield,
+			Options: opts,
+			Parent:  v,
+		}
+
+		if opts.Source != "" && f.rendersMarkdown() {
+			fchanged, err = f.convertFromSource(sources, field)
+		} else {
+			fchanged, err = f.convert(field)
+		}
 		changed = fchanged || changed
 
 		if err != nil {
@@ -301,26 +698,255 @@ func (f *fieldProcessor) convertString(v reflect.Value) (bool, error) {
 	}
 
 	value := v.String()
-	rendered, err := f.renderString(value)
+	rendered, err := f.visitor.Visit(f.field, value)
 	if err != nil {
-		return false, err
+		return false, f.handleError(err)
 	}
 
-	if !f.ValidateOnly {
-		v.SetString(rendered)
-	}
+	f.write(fieldChange{
+		target: v,
+		old:    reflect.ValueOf(value),
+		new:    reflect.ValueOf(rendered),
+	})
 
 	return value != rendered, err
 }
 
+// handleError applies the ErrorPolicy to err, an error rendering the field
+// being converted.  handleError returns the error to stop conversion with,
+// or nil to carry on with the next field, leaving this one unchanged.
+// Fields skipped by a BeforeRenderFunc are left unchanged without failing.
+func (f *fieldProcessor) handleError(err error) error {
+	if errors.Is(err, errSkipped) {
+		return nil
+	}
+
+	ferr, ok := err.(*FieldError)
+	if !ok {
+		ferr = f.fieldError(err)
+	}
+
+	f.fieldFailed(ferr)
+
+	switch f.ErrorPolicy {
+	case ContinueOnError:
+		f.errors = append(f.errors, ferr)
+		return nil
+	case SkipOnError:
+		return nil
+	}
+
+	return ferr
+}
+
+// fieldError wraps err, an error rendering the field being converted, in a
+// *FieldError locating the field.
+func (f *fieldProcessor) fieldError(err error) *FieldError {
+	ferr := &FieldError{Path: f.field.Path, Err: err}
+
+	if perr, ok := err.(*panicError); ok {
+		ferr.Panic, ferr.Stack = perr.value, perr.stack
+	}
+
+	return ferr
+}
+
+// write replaces the value described by change with its rendered value,
+// unless the fieldProcessor is only validating.  In Atomic mode, the write
+// is deferred until run completes.
+func (f *fieldProcessor) write(change fieldChange) {
+	change.path = f.field.Path
+
+	if f.RecordChanges {
+		f.changes = append(f.changes, change)
+	}
+
+	switch {
+	case f.ValidateOnly:
+	case f.Atomic:
+		f.pending = append(f.pending, change)
+	default:
+		change.apply(change.new)
+	}
+}
+
+// changed reports whether the rendered value differs from the original.
+func (c fieldChange) changed() bool {
+	return !reflect.DeepEqual(c.old.Interface(), c.new.Interface())
+}
+
+// apply sets the value described by the change to v.
+func (c fieldChange) apply(v reflect.Value) {
+	if c.key.IsValid() {
+		c.target.SetMapIndex(c.key, v.Convert(c.target.Type().Elem()))
+		return
+	}
+	c.target.Set(v.Convert(c.target.Type()))
+}
+
 func (f *fieldProcessor) renderString(s string) (string, error) {
-	b := &strings.Builder{}
-	err := f.writeMarkdown([]byte(s), b)
-	return b.String(), err
+	rendered, err := f.render([]byte(s))
+	return string(rendered), err
 }
 
-func (f *fieldProcessor) writeMarkdown(source []byte, w io.Writer) error {
-	return f.converter.markdown.Convert(source, w, f.parseOptions...)
+// render renders the Markdown source of the field being converted,
+// reporting the field to the converter's Observers if it succeeds.
+func (f *fieldProcessor) render(source []byte) ([]byte, error) {
+	if len(f.converter.observers) == 0 {
+		return f.renderField(source)
+	}
+
+	start := time.Now()
+	f.cached = false
+
+	rendered, err := f.renderField(source)
+	if err != nil {
+		return rendered, err
+	}
+
+	f.fieldRendered(RenderStats{
+		Duration: time.Since(start),
+		BytesIn:  len(source),
+		BytesOut: len(rendered),
+		Cached:   f.cached,
+	})
+
+	return rendered, nil
+}
+
+// errSkipped is returned by renderField when a BeforeRenderFunc skips the
+// field, which is then left unchanged.
+var errSkipped = errors.New("field skipped")
+
+func (f *fieldProcessor) renderField(source []byte) (rendered []byte, err error) {
+	if f.converter.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				rendered, err = nil, &panicError{value: r, stack: debug.Stack()}
+			}
+		}()
+	}
+
+	for _, before := range f.converter.beforeRender {
+		var skip bool
+		source, skip, err = before(f.field, source)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return nil, errSkipped
+		}
+	}
+
+	pipeline, err := f.pipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	if source, err = f.runStages(pipeline.Preprocessors, source); err != nil {
+		return nil, err
+	}
+
+	if err := f.checkSource(source); err != nil {
+		return nil, err
+	}
+
+	if len(f.Rules) > 0 {
+		f.lint(source)
+	}
+
+	if f.LintOnly {
+		return source, nil
+	}
+
+	if rendered, err = f.renderSanitized(source); err != nil {
+		return nil, err
+	}
+
+	if rendered, err = f.runStages(pipeline.Postprocessors, rendered); err != nil {
+		return nil, err
+	}
+
+	if f.converter.minify {
+		rendered = MinifyHTML(rendered)
+	}
+
+	for _, after := range f.converter.afterRender {
+		if rendered, err = after(f.field, rendered); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.checkOutput(rendered); err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+func (f *fieldProcessor) renderSanitized(source []byte) ([]byte, error) {
+	sanitizer, err := f.sanitizer()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	if err := f.converter.renderer.Render(f.context(), source, f.limitOutput(b), f.field); err != nil {
+		return b.Bytes(), err
+	}
+
+	rendered := b.Bytes()
+	if sanitizer != nil {
+		rendered = sanitizer.Sanitize(rendered)
+	}
+
+	return rendered, nil
+}
+
+// sanitizer returns the Sanitizer to apply to the field being converted, or
+// nil if its rendered HTML should be left as is.
+func (f *fieldProcessor) sanitizer() (Sanitizer, error) {
+	switch name := f.field.Options.Sanitize; name {
+	case "":
+		if f.field.Options.Trusted {
+			return nil, nil
+		}
+		return f.converter.sanitizer, nil
+	case PolicyNone:
+		return nil, nil
+	default:
+		s, ok := f.converter.policies[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownPolicy, name)
+		}
+		return s, nil
+	}
+}
+
+// markdown returns the goldmark.Markdown used to parse the field being
+// converted.
+func (f *fieldProcessor) markdown() goldmark.Markdown {
+	return f.converter.markdownFor(f.field)
+}
+
+// sortedMapKeys returns the keys of map v in a stable order, so that map
+// entries are converted, reported and collected deterministically.
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+func joinPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	if name == "" {
+		return parent
+	}
+	return parent + "." + name
 }
 
 func isStruct(v reflect.Value) bool {
@@ -348,22 +974,7 @@ func isStruct(v reflect.Value) bool {
 }
 
 func isMarkdownTagEnabled(tag reflect.StructTag) bool {
-	tagval := tag.Get(structTagKey)
-	switch strings.ToLower(tagval) {
-	case "on", "yes", "1", "y", "enable":
-		return true
-	}
-	return false
-}
-
-func isStructFieldTagEnabled(structval reflect.Value, fieldIdx int) bool {
-	if structval.Kind() != reflect.Struct {
-		return false
-	}
-
-	structfield := structval.Type().Field(fieldIdx)
-	fieldtag := structfield.Tag
-	return isMarkdownTagEnabled(fieldtag)
+	return parseTag(tag).Enabled
 }
 
 func isValidSettable(v reflect.Value) bool {
@@ -371,8 +982,10 @@ func isValidSettable(v reflect.Value) bool {
 }
 
 func makeFieldProcessor(c *converter, opts ...parser.ParseOption) *fieldProcessor {
-	return &fieldProcessor{
+	f := &fieldProcessor{
 		converter:    c,
 		parseOptions: opts,
 	}
+	f.visitor = markdownVisitor{fieldproc: f}
+	return f
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
 )
 
 type MyStruct struct {
@@ -706,3 +707,111 @@ func TestConvertSliceValue(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "<p>one</p>\n", test[0])
 }
+
+type TrustedPage struct {
+	Banner string `markdown:"on,trusted"`
+	Body   string `markdown:"on"`
+}
+
+func TestConvertTrustedFields(t *testing.T) {
+	page := &TrustedPage{
+		Banner: `<div class="banner">Sale</div>`,
+		Body:   `<div class="banner">Sale</div>`,
+	}
+
+	changed, err := ConvertFields(page)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<div class=\"banner\">Sale</div>", page.Banner)
+	assert.Equal(t, "<!-- raw HTML omitted -->\n", page.Body)
+}
+
+func TestWithTrustedMarkdown(t *testing.T) {
+	raw := "<b>bold</b>"
+
+	untrusted := WithMarkdown(goldmark.New())
+
+	page := &TrustedPage{Banner: raw, Body: raw}
+
+	changed, err := untrusted.ConvertFields(page)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><!-- raw HTML omitted -->bold<!-- raw HTML omitted --></p>\n", page.Banner)
+	assert.Equal(t, page.Banner, page.Body)
+
+	trusted := WithMarkdown(
+		goldmark.New(),
+		WithTrustedMarkdown(goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))),
+		WithSanitizer(StrictPolicy()),
+	)
+
+	page = &TrustedPage{Banner: raw, Body: raw}
+
+	changed, err = trusted.ConvertFields(page)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><b>bold</b></p>\n", page.Banner)
+	assert.Equal(t, "<p>bold</p>\n", page.Body)
+}
+
+func TestTrustedFieldWithPolicy(t *testing.T) {
+	type Page struct {
+		Banner string `markdown:"on,trusted,sanitize=strict"`
+	}
+
+	page := &Page{Banner: `<em onclick="steal()">hi</em>`}
+
+	changed, err := ConvertFields(page)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><em>hi</em></p>\n", page.Banner)
+}
+
+func TestConvertAtomic(t *testing.T) {
+	type Test struct {
+		First  string            `markdown:"on"`
+		Tags   []string          `markdown:"on"`
+		Notes  map[string]string `markdown:"on"`
+		Second string            `markdown:"on,sanitize=missing"`
+	}
+
+	atomic := WithMarkdown(goldmark.New(), WithAtomic(true))
+
+	test := &Test{
+		First:  "*one*",
+		Tags:   []string{"_a_"},
+		Notes:  map[string]string{"n": "**b**"},
+		Second: "*two*",
+	}
+
+	changed, err := atomic.ConvertFields(test)
+	assert.False(t, changed)
+	assert.True(t, errors.Is(err, ErrUnknownPolicy))
+
+	assert.Equal(t, "*one*", test.First)
+	assert.Equal(t, []string{"_a_"}, test.Tags)
+	assert.Equal(t, map[string]string{"n": "**b**"}, test.Notes)
+	assert.Equal(t, "*two*", test.Second)
+
+	snap, err := atomic.ConvertFieldsSnapshot(test)
+	assert.Error(t, err)
+	assert.False(t, snap.Changed())
+	assert.Equal(t, "*one*", test.First)
+
+	// without atomic conversion, earlier fields are left converted
+	changed, err = ConvertFields(test)
+	assert.True(t, changed)
+	assert.Error(t, err)
+	assert.Equal(t, "<p><em>one</em></p>\n", test.First)
+
+	valid := &MyAnnotatedEnabledStruct{Comment: "*one*"}
+
+	changed, err = atomic.ConvertFields(valid)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>one</em></p>\n", valid.Comment)
+}
@@ -0,0 +1,174 @@
+package markstruct
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDurationBuckets are the upper bounds of the duration histograms of
+// Metrics created without buckets.
+var DefaultDurationBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Metrics is an Observer aggregating counters and histograms in memory,
+// for exposure through a metrics endpoint.  Metrics is safe for concurrent
+// use.  The zero value is ready to use, with DefaultDurationBuckets.
+type Metrics struct {
+	mu     sync.Mutex
+	values MetricValues
+}
+
+// MetricValues holds the counters and histograms aggregated by Metrics.
+type MetricValues struct {
+	// Calls and CallErrors count calls, and calls returning an error, by
+	// operation, e.g. "ConvertFields".
+	Calls      map[string]int64
+	CallErrors map[string]int64
+
+	// FieldsRendered and FieldsFailed count fields rendered successfully,
+	// and fields failing to render.  CacheHits counts the rendered fields
+	// whose HTML the Renderer took from a cache.
+	FieldsRendered int64
+	FieldsFailed   int64
+	CacheHits      int64
+
+	// BytesIn and BytesOut total the sizes of the Markdown and HTML of
+	// rendered fields.
+	BytesIn  int64
+	BytesOut int64
+
+	CallDuration   Histogram
+	RenderDuration Histogram
+}
+
+// Histogram counts durations falling within buckets.
+type Histogram struct {
+	// Bounds holds the inclusive upper bound of each bucket, in increasing
+	// order.
+	Bounds []time.Duration
+
+	// Counts holds the number of durations within each bucket, followed by
+	// the number of durations exceeding the last bound.
+	Counts []int64
+
+	Count int64
+	Sum   time.Duration
+}
+
+// NewMetrics returns Metrics whose duration histograms use the given bucket
+// upper bounds, or DefaultDurationBuckets if none are given.
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+
+	bounds := append([]time.Duration(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	m := &Metrics{}
+	m.init(bounds)
+	return m
+}
+
+// init sets up the counters and histograms of m, unless already done, with
+// buckets bounded by bounds, which must be sorted.
+func (m *Metrics) init(bounds []time.Duration) {
+	if m.values.Calls != nil {
+		return
+	}
+
+	bounds = append([]time.Duration(nil), bounds...)
+
+	m.values.Calls = map[string]int64{}
+	m.values.CallErrors = map[string]int64{}
+	m.values.CallDuration = newHistogram(bounds)
+	m.values.RenderDuration = newHistogram(bounds)
+}
+
+// Values returns a copy of the current counters and histograms.
+func (m *Metrics) Values() MetricValues {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init(DefaultDurationBuckets)
+
+	values := m.values
+	values.Calls = copyCounts(m.values.Calls)
+	values.CallErrors = copyCounts(m.values.CallErrors)
+	values.CallDuration = m.values.CallDuration.copy()
+	values.RenderDuration = m.values.RenderDuration.copy()
+
+	return values
+}
+
+func (m *Metrics) CallStarted(call CallInfo) {}
+
+func (m *Metrics) CallFinished(call CallInfo, result CallResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init(DefaultDurationBuckets)
+
+	m.values.Calls[call.Operation]++
+	if result.Err != nil {
+		m.values.CallErrors[call.Operation]++
+	}
+	m.values.CallDuration.observe(result.Duration)
+}
+
+func (m *Metrics) FieldRendered(field FieldInfo, stats RenderStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init(DefaultDurationBuckets)
+
+	m.values.FieldsRendered++
+	if stats.Cached {
+		m.values.CacheHits++
+	}
+	m.values.BytesIn += int64(stats.BytesIn)
+	m.values.BytesOut += int64(stats.BytesOut)
+	m.values.RenderDuration.observe(stats.Duration)
+}
+
+func (m *Metrics) FieldFailed(field FieldInfo, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values.FieldsFailed++
+}
+
+func newHistogram(bounds []time.Duration) Histogram {
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]int64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h Histogram) copy() Histogram {
+	h.Bounds = append([]time.Duration(nil), h.Bounds...)
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	c := make(map[string]int64, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
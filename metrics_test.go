@@ -0,0 +1,77 @@
+package markstruct
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	conv := WithMarkdown(
+		&FussyMarkdown{goldmark.New()},
+		WithObserver(metrics),
+		WithErrorPolicy(ContinueOnError),
+	)
+
+	_, err := conv.ConvertFields(newBulkImport())
+	assert.Error(t, err)
+
+	_, err = conv.ConvertFields(&BulkImport{Body: "*body*"})
+	assert.NoError(t, err)
+
+	values := metrics.Values()
+	assert.Equal(t, map[string]int64{"ConvertFields": 2}, values.Calls)
+	assert.Equal(t, map[string]int64{"ConvertFields": 1}, values.CallErrors)
+	assert.Equal(t, int64(7), values.FieldsRendered)
+	assert.Equal(t, int64(3), values.FieldsFailed)
+	assert.Equal(t, int64(0), values.CacheHits)
+	assert.Equal(t, int64(len("*a**c**y**z**body**body*")), values.BytesIn)
+
+	assert.Equal(t, int64(2), values.CallDuration.Count)
+	assert.Equal(t, int64(7), values.RenderDuration.Count)
+	assert.Len(t, values.RenderDuration.Counts, len(DefaultDurationBuckets)+1)
+}
+
+func TestMetricsHistogram(t *testing.T) {
+	metrics := NewMetrics(time.Second, time.Millisecond)
+	call := CallInfo{Operation: "ConvertFields"}
+
+	metrics.CallFinished(call, CallResult{Duration: time.Microsecond})
+	metrics.CallFinished(call, CallResult{Duration: time.Millisecond})
+	metrics.CallFinished(call, CallResult{Duration: 2 * time.Millisecond})
+	metrics.CallFinished(call, CallResult{Duration: time.Minute, Err: errors.New("BOOM")})
+
+	h := metrics.Values().CallDuration
+	assert.Equal(t, []time.Duration{time.Millisecond, time.Second}, h.Bounds)
+	assert.Equal(t, []int64{2, 1, 1}, h.Counts)
+	assert.Equal(t, int64(4), h.Count)
+	assert.Equal(t, time.Minute+3*time.Millisecond+time.Microsecond, h.Sum)
+
+	// Values returns a copy
+	h.Counts[0] = 100
+	assert.Equal(t, int64(2), metrics.Values().CallDuration.Counts[0])
+}
+
+func TestMetricsZeroValue(t *testing.T) {
+	metrics := &Metrics{}
+	assert.Empty(t, metrics.Values().Calls)
+
+	metrics = &Metrics{}
+	metrics.FieldFailed(FieldInfo{}, errors.New("BOOM"))
+
+	conv := WithMarkdown(goldmark.New(), WithObserver(metrics))
+
+	_, err := conv.ConvertFields(&BulkImport{Body: "*body*"})
+	assert.NoError(t, err)
+
+	values := metrics.Values()
+	assert.Equal(t, map[string]int64{"ConvertFields": 1}, values.Calls)
+	assert.Equal(t, int64(1), values.FieldsFailed)
+	assert.Equal(t, DefaultDurationBuckets, values.RenderDuration.Bounds)
+	assert.Equal(t, values.FieldsRendered, values.RenderDuration.Count)
+}
@@ -0,0 +1,87 @@
+package markstruct
+
+import (
+	"bytes"
+)
+
+// preservedElements lists the elements whose content MinifyHTML leaves
+// untouched, as their whitespace is significant.
+var preservedElements = map[string]bool{
+	"code":     true,
+	"pre":      true,
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+}
+
+// blockElements lists the elements around which whitespace does not affect
+// rendering, and is dropped by MinifyHTML.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "dd": true, "details": true, "div": true, "dl": true,
+	"dt": true, "figcaption": true, "figure": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true,
+	"thead": true, "tr": true, "ul": true,
+}
+
+// MinifyHTML returns html with insignificant whitespace removed, as used by
+// converters created with WithMinify.  Runs of whitespace are collapsed to
+// a single space, and dropped entirely next to block-level tags such as
+// paragraphs and list items, including the newlines `goldmark` writes after
+// them.  The content of `<pre>`, `<code>`, `<textarea>`, `<script>` and
+// `<style>` elements is left untouched.
+func MinifyHTML(html []byte) []byte {
+	out := &bytes.Buffer{}
+	out.Grow(len(html))
+
+	// space is set while skipping whitespace, which is written as a single
+	// space before the next text or inline tag.  block is set following
+	// a block-level tag, after which whitespace is dropped.
+	space, block := false, true
+
+	for i := 0; i < len(html); {
+		c := html[i]
+
+		if c == '<' {
+			tok, n := scanTag(html[i:])
+			if n > 0 {
+				isBlock := blockElements[tok.name]
+				if space && !block && !isBlock {
+					out.WriteByte(' ')
+				}
+				space = false
+
+				if !tok.closing && !tok.selfClosing && preservedElements[tok.name] {
+					n += skipElementContent(html[i+n:], tok.name)
+				}
+
+				out.Write(html[i : i+n])
+				i += n
+
+				if !tok.comment {
+					block = isBlock
+				}
+				continue
+			}
+		}
+
+		if isSpace(c) {
+			space = true
+			i++
+			continue
+		}
+
+		if space && !block {
+			out.WriteByte(' ')
+		}
+		space, block = false, false
+
+		out.WriteByte(c)
+		i++
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,83 @@
+package markstruct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "block newlines",
+			html: "<h1>Title</h1>\n<p>One</p>\n<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n",
+			want: "<h1>Title</h1><p>One</p><ul><li>a</li><li>b</li></ul>",
+		},
+		{
+			name: "soft line breaks",
+			html: "<p>one\ntwo  \t three</p>\n",
+			want: "<p>one two three</p>",
+		},
+		{
+			name: "inline whitespace",
+			html: "<p><em>a</em> <strong>b</strong>\n<a href=\"x\">c</a></p>",
+			want: "<p><em>a</em> <strong>b</strong> <a href=\"x\">c</a></p>",
+		},
+		{
+			name: "hard line breaks",
+			html: "<p>one<br />\ntwo</p>\n",
+			want: "<p>one<br />two</p>",
+		},
+		{
+			name: "preformatted",
+			html: "<pre><code class=\"language-go\">if x {\n    y()\n}\n</code></pre>\n<p>a  <code>b   c</code>  d</p>\n",
+			want: "<pre><code class=\"language-go\">if x {\n    y()\n}\n</code></pre><p>a <code>b   c</code> d</p>",
+		},
+		{
+			name: "script and style",
+			html: "<style>\np  >  a { }\n</style>\n<script>if (a < b) {\n  x()\n}</script>\n",
+			want: "<style>\np  >  a { }\n</style> <script>if (a < b) {\n  x()\n}</script>",
+		},
+		{
+			name: "comments",
+			html: "<p>a <!-- note --> b</p>",
+			want: "<p>a <!-- note --> b</p>",
+		},
+		{
+			name: "text",
+			html: "  plain   text  ",
+			want: "plain text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(MinifyHTML([]byte(tt.html))))
+		})
+	}
+}
+
+func TestWithMinify(t *testing.T) {
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithMinify(true),
+		WithAfterRender(func(field FieldInfo, html []byte) ([]byte, error) {
+			return append(html, '\n'), nil
+		}),
+	)
+
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	test := &Post{Body: "# Title\n\nSome *text*\nhere.\n\n```\ncode  block\n```\n"}
+	_, err := conv.ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>Title</h1><p>Some <em>text</em> here.</p><pre><code>code  block\n</code></pre>\n", test.Body)
+}
@@ -0,0 +1,217 @@
+package markstruct
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// nodeText returns the plain text content of n.
+func nodeText(n ast.Node, source []byte) []byte {
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		b := &bytes.Buffer{}
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			b.Write(line.Value(source))
+		}
+		return bytes.TrimRight(b.Bytes(), "\n")
+	}
+
+	if raw, ok := n.(*ast.RawHTML); ok {
+		b := &bytes.Buffer{}
+		for i := 0; i < raw.Segments.Len(); i++ {
+			segment := raw.Segments.At(i)
+			b.Write(segment.Value(source))
+		}
+		return b.Bytes()
+	}
+
+	return n.Text(source)
+}
+
+// nodePosition returns the line and column, counting from 1, at which the
+// construct n starts in source, including its markers such as the '#' of a
+// heading or the "![" of an image.
+func nodePosition(n ast.Node, source []byte) (int, int) {
+	if offset, ok := nodeStart(n, source); ok {
+		return offsetPosition(source, offset)
+	}
+	return offsetPosition(source, emptyNodeStart(n, source))
+}
+
+// nodeStart returns the offset at which n starts in source, found from the
+// segments of n or of its first descendant, or false if n has no content.
+func nodeStart(n ast.Node, source []byte) (int, bool) {
+	switch n := n.(type) {
+	case *ast.Text:
+		return n.Segment.Start, true
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			return n.Segments.At(0).Start, true
+		}
+		return 0, false
+	case *ast.FencedCodeBlock:
+		// the content of a fenced code block starts on the line after its
+		// fence, which only the info string, if any, is located on
+		if n.Info == nil {
+			return 0, false
+		}
+		start := skipBack(source, n.Info.Segment.Start, " \t", -1)
+		return skipBack(source, start, "`~", -1), true
+	case *ast.AutoLink:
+		return 0, false
+	}
+
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		start := n.Lines().At(0).Start
+
+		switch n.Kind() {
+		case ast.KindHeading:
+			// ATX headings are preceded by '#' markers, setext headings by
+			// nothing
+			if marker := skipBack(source, start, " \t", -1); marker > 0 && source[marker-1] == '#' {
+				start = skipBack(source, marker, "#", -1)
+			}
+		case ast.KindCodeBlock:
+			start = skipBack(source, start, " \t", -1)
+		}
+
+		return start, true
+	}
+
+	c := n.FirstChild()
+	if c == nil {
+		return 0, false
+	}
+
+	start, ok := nodeStart(c, source)
+	if !ok {
+		return 0, false
+	}
+
+	return openingStart(n, source, start), true
+}
+
+// openingStart returns the offset of the opening markers of n, given the
+// offset start of its first child.
+func openingStart(n ast.Node, source []byte, start int) int {
+	switch n := n.(type) {
+	case *ast.Emphasis:
+		return skipBack(source, start, "*_", n.Level)
+	case *ast.Link:
+		return skipBack(source, start, "[", 1)
+	case *ast.Image:
+		return skipBack(source, skipBack(source, start, "[", 1), "!", 1)
+	case *ast.CodeSpan:
+		return skipBack(source, skipBack(source, start, " ", 1), "`", -1)
+	case *ast.Blockquote:
+		return skipBack(source, skipBack(source, start, " \t", -1), ">", 1)
+	case *ast.ListItem:
+		start = skipBack(source, start, " \t", -1)
+		if marker := skipBack(source, start, "-+*", 1); marker < start {
+			return marker
+		}
+		if marker := skipBack(source, start, ".)", 1); marker < start {
+			return skipBack(source, marker, "0123456789", -1)
+		}
+		return start
+	}
+
+	if n.Kind() == east.KindStrikethrough {
+		return skipBack(source, start, "~", -1)
+	}
+
+	return start
+}
+
+// emptyNodeStart returns the offset at which n starts in source, for nodes
+// without content to locate them by, such as links without text.  The
+// construct is searched for following the preceding node.
+func emptyNodeStart(n ast.Node, source []byte) int {
+	from := 0
+	for m := n; m != nil; m = m.Parent() {
+		if prev := m.PreviousSibling(); prev != nil {
+			from = nodeEnd(prev, source)
+			break
+		}
+
+		if parent := m.Parent(); parent != nil && parent.Type() == ast.TypeBlock && parent.Lines().Len() > 0 {
+			from = parent.Lines().At(0).Start
+			break
+		}
+	}
+
+	if from > len(source) {
+		from = len(source)
+	}
+
+	var marker []byte
+	switch n := n.(type) {
+	case *ast.Link:
+		marker = []byte("[")
+	case *ast.Image:
+		marker = []byte("![")
+	case *ast.AutoLink:
+		marker = n.Label(source)
+	}
+
+	if len(marker) > 0 {
+		if idx := bytes.Index(source[from:], marker); idx >= 0 {
+			start := from + idx
+			if _, ok := n.(*ast.AutoLink); ok {
+				start = skipBack(source, start, "<", 1)
+			}
+			return start
+		}
+	}
+
+	for from < len(source) && isSpace(source[from]) {
+		from++
+	}
+	return from
+}
+
+// nodeEnd returns an offset in source no earlier than the end of node n, and
+// before the start of any node following it.
+func nodeEnd(n ast.Node, source []byte) int {
+	if t, ok := n.(*ast.Text); ok {
+		return t.Segment.Stop
+	}
+
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		return n.Lines().At(n.Lines().Len() - 1).Stop
+	}
+
+	if n.Type() == ast.TypeBlock && n.LastChild() != nil {
+		return nodeEnd(n.LastChild(), source)
+	}
+
+	// the end of inline constructs, such as the destination of a link, is
+	// not recorded, so settle for just past their start
+	if start, ok := nodeStart(n, source); ok {
+		return start + 1
+	}
+	return emptyNodeStart(n, source) + 1
+}
+
+// skipBack returns offset moved back over at most max bytes of source found
+// in chars, or over any number of them if max is negative.
+func skipBack(source []byte, offset int, chars string, max int) int {
+	for offset > 0 && max != 0 && bytes.IndexByte([]byte(chars), source[offset-1]) >= 0 {
+		offset--
+		max--
+	}
+	return offset
+}
+
+func offsetPosition(source []byte, offset int) (int, int) {
+	if offset > len(source) {
+		offset = len(source)
+	}
+
+	line := 1 + bytes.Count(source[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(source[:offset], '\n')
+	return line, column
+}
@@ -0,0 +1,69 @@
+package markstruct
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestNodePosition(t *testing.T) {
+	tests := []struct {
+		source string
+		kind   ast.NodeKind
+		line   int
+		column int
+	}{
+		{"## Five stars", ast.KindHeading, 1, 1},
+		{"  ### Indented", ast.KindHeading, 1, 3},
+		{"Setext\n===", ast.KindHeading, 1, 1},
+		{"Look\nhere ![cat](cat.png)", ast.KindImage, 2, 6},
+		{"Look ![](cat.png)", ast.KindImage, 1, 6},
+		{"[a](/a) and [](/b)", ast.KindLink, 1, 13},
+		{"see [](/b)", ast.KindLink, 1, 5},
+		{"see [*a*](/b)", ast.KindLink, 1, 5},
+		{"very **bold**", ast.KindEmphasis, 1, 6},
+		{"a `code` span", ast.KindCodeSpan, 1, 3},
+		{"a ~~gone~~", east.KindStrikethrough, 1, 3},
+		{"mail <https://example.com>", ast.KindAutoLink, 1, 6},
+		{"intro\n\n> quoted", ast.KindBlockquote, 3, 1},
+		{"intro\n\n- one\n  - two", ast.KindListItem, 4, 3},
+		{"intro\n\n10. ten", ast.KindListItem, 3, 1},
+		{"intro\n\n```go\nx()\n```", ast.KindFencedCodeBlock, 3, 1},
+		{"intro\n\n```\nx()\n```", ast.KindFencedCodeBlock, 3, 1},
+		{"intro\n\n    x()", ast.KindCodeBlock, 3, 1},
+		{"intro\n\n---", ast.KindThematicBreak, 3, 1},
+		{"a <br> b", ast.KindRawHTML, 1, 3},
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(extension.Strikethrough))
+
+	for _, tt := range tests {
+		t.Run(strings.ReplaceAll(tt.source, "\n", `\n`), func(t *testing.T) {
+			source := []byte(tt.source)
+			doc := md.Parser().Parse(text.NewReader(source))
+
+			// locate the last node of the kind
+			var found ast.Node
+			walkNodes(doc, func(n ast.Node) {
+				if n.Kind() == tt.kind {
+					found = n
+				}
+			})
+
+			if found == nil {
+				t.Fatalf("no %s node", tt.kind)
+			}
+
+			line, column := nodePosition(found, source)
+			assert.Equal(t, tt.line, line)
+			assert.Equal(t, tt.column, column)
+		})
+	}
+}
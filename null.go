@@ -0,0 +1,54 @@
+package markstruct
+
+import (
+	"reflect"
+)
+
+// nullStringField returns the index of the string field of struct type t if
+// t wraps an optional string the way `sql.NullString` and `sql.Null[string]`
+// do: a `Valid bool` field alongside a single field of string kind named
+// String or V.  Structs tagging their own fields with `markdown` are
+// converted by their tags instead.  nullStringField returns -1 for other
+// types.
+func nullStringField(t reflect.Type) int {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return -1
+	}
+
+	valid, ok := t.FieldByName("Valid")
+	if !ok || valid.Type.Kind() != reflect.Bool || len(valid.Index) != 1 {
+		return -1
+	}
+
+	other := 1 - valid.Index[0]
+	field := t.Field(other)
+	if field.Type.Kind() != reflect.String || (field.Name != "String" && field.Name != "V") {
+		return -1
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup(structTagKey); ok {
+			return -1
+		}
+	}
+
+	return other
+}
+
+// isNullString reports whether t, or the type t points to, wraps an optional
+// string as recognized by nullStringField.
+func isNullString(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return nullStringField(t) >= 0
+}
+
+// convertNullString converts the string of v, a struct recognized by
+// nullStringField, if it is Valid.  Invalid values are left untouched.
+func (f *fieldProcessor) convertNullString(v reflect.Value, field int) (bool, error) {
+	if !v.FieldByName("Valid").Bool() {
+		return false, nil
+	}
+	return f.convertString(v.Field(field))
+}
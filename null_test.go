@@ -0,0 +1,125 @@
+package markstruct
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// NullMarkdown wraps an optional string like the generic sql.Null type.
+type NullMarkdown struct {
+	V     Markdownish
+	Valid bool
+}
+
+type Markdownish string
+
+func TestNullStringField(t *testing.T) {
+	assert.Equal(t, 0, nullStringField(reflect.TypeOf(sql.NullString{})))
+	assert.Equal(t, 0, nullStringField(reflect.TypeOf(NullMarkdown{})))
+
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf(sql.NullInt64{})))
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf(struct {
+		String string
+		Other  string
+	}{})))
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf(struct {
+		String string
+		Valid  bool
+		Extra  int
+	}{})))
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf(struct {
+		Text  string
+		Valid bool
+	}{})))
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf(struct {
+		String string `markdown:"on"`
+		Valid  bool
+	}{})))
+	assert.Equal(t, -1, nullStringField(reflect.TypeOf("")))
+}
+
+func TestConvertValidStruct(t *testing.T) {
+	type Inner struct {
+		Text  string `markdown:"on"`
+		Valid bool
+	}
+
+	type Tagged struct {
+		Text  string `markdown:"on"`
+		Valid bool
+	}
+
+	type Post struct {
+		Inner  Inner
+		Tagged Tagged `markdown:"on"`
+		Plain  struct {
+			Text  string
+			Valid bool
+		} `markdown:"on"`
+	}
+
+	test := &Post{
+		Inner:  Inner{Text: "*x*"},
+		Tagged: Tagged{Text: "*x*"},
+	}
+	test.Plain.Text = "*x*"
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Inner{Text: "<p><em>x</em></p>\n"}, test.Inner)
+	assert.Equal(t, Tagged{Text: "<p><em>x</em></p>\n"}, test.Tagged)
+	assert.Equal(t, "*x*", test.Plain.Text)
+}
+
+func TestConvertNullString(t *testing.T) {
+	type Post struct {
+		Title    sql.NullString   `markdown:"on"`
+		Summary  *sql.NullString  `markdown:"on"`
+		Notes    []sql.NullString `markdown:"on"`
+		Body     NullMarkdown     `markdown:"on"`
+		Missing  sql.NullString   `markdown:"on"`
+		Untagged sql.NullString
+	}
+
+	test := &Post{
+		Title:    sql.NullString{String: "*title*", Valid: true},
+		Summary:  &sql.NullString{String: "*summary*", Valid: true},
+		Notes:    []sql.NullString{{String: "*note*", Valid: true}, {String: "*null*"}},
+		Body:     NullMarkdown{V: "*body*", Valid: true},
+		Missing:  sql.NullString{String: "*missing*"},
+		Untagged: sql.NullString{String: "*untagged*", Valid: true},
+	}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql.NullString{String: "<p><em>title</em></p>\n", Valid: true}, test.Title)
+	assert.Equal(t, &sql.NullString{String: "<p><em>summary</em></p>\n", Valid: true}, test.Summary)
+	assert.Equal(t, []sql.NullString{{String: "<p><em>note</em></p>\n", Valid: true}, {String: "*null*"}}, test.Notes)
+	assert.Equal(t, NullMarkdown{V: "<p><em>body</em></p>\n", Valid: true}, test.Body)
+	assert.Equal(t, sql.NullString{String: "*missing*"}, test.Missing)
+	assert.Equal(t, sql.NullString{String: "*untagged*", Valid: true}, test.Untagged)
+}
+
+func TestConvertAllFieldsNullString(t *testing.T) {
+	type Post struct {
+		Title sql.NullString
+	}
+
+	test := &Post{Title: sql.NullString{String: "*title*", Valid: true}}
+
+	previews, err := Preview(test)
+	assert.NoError(t, err)
+	assert.Empty(t, previews)
+
+	changed, err := ConvertAllFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>title</em></p>\n", test.Title.String)
+}
@@ -0,0 +1,143 @@
+package markstruct
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// Observer receives events from a FieldConverter, for collecting metrics or
+// logging.  Observers are added with WithObserver.  A FieldConverter used
+// from several goroutines calls its Observers concurrently.
+type Observer interface {
+	// CallStarted is called when a FieldConverter method starts processing
+	// a struct.
+	CallStarted(call CallInfo)
+
+	// CallFinished is called when a FieldConverter method is done
+	// processing a struct.
+	CallFinished(call CallInfo, result CallResult)
+
+	// FieldRendered is called for every field rendered successfully.
+	FieldRendered(field FieldInfo, stats RenderStats)
+
+	// FieldFailed is called for every field that fails to render, with its
+	// *FieldError, whatever the ErrorPolicy of the FieldConverter.
+	FieldFailed(field FieldInfo, err error)
+}
+
+// CallInfo describes a call to a FieldConverter method.
+type CallInfo struct {
+	// Operation is the name of the method called, e.g. "ConvertFields".
+	Operation string
+
+	// Type is the type of the struct being processed.
+	Type reflect.Type
+}
+
+// CallResult describes the outcome of a call to a FieldConverter method.
+type CallResult struct {
+	Duration time.Duration
+
+	// Changed reports whether any field was, or would be, changed.
+	Changed bool
+
+	Err error
+}
+
+// RenderStats describes the rendering of a field.
+type RenderStats struct {
+	Duration time.Duration
+
+	// BytesIn and BytesOut are the sizes of the field's Markdown and of
+	// the HTML rendered from it.
+	BytesIn  int
+	BytesOut int
+
+	// Cached reports whether the Renderer took the HTML from a cache
+	// rather than rendering it, as reported with ReportCacheHit.
+	Cached bool
+}
+
+// SlogObserver returns an Observer writing events as `log/slog` records to
+// logger.  Calls are logged at Info level, or Error level if they fail,
+// field failures at Warn level, and call starts and rendered fields at
+// Debug level.
+func SlogObserver(logger *slog.Logger) Observer {
+	return &slogObserver{logger: logger}
+}
+
+type slogObserver struct {
+	logger *slog.Logger
+}
+
+func (o *slogObserver) CallStarted(call CallInfo) {
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, "markstruct call started",
+		slog.String("operation", call.Operation),
+		slog.String("type", call.Type.String()),
+	)
+}
+
+func (o *slogObserver) CallFinished(call CallInfo, result CallResult) {
+	attrs := []slog.Attr{
+		slog.String("operation", call.Operation),
+		slog.String("type", call.Type.String()),
+		slog.Duration("duration", result.Duration),
+		slog.Bool("changed", result.Changed),
+	}
+
+	level := slog.LevelInfo
+	if result.Err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", result.Err.Error()))
+	}
+
+	o.logger.LogAttrs(context.Background(), level, "markstruct call finished", attrs...)
+}
+
+func (o *slogObserver) FieldRendered(field FieldInfo, stats RenderStats) {
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, "markstruct field rendered",
+		slog.String("path", field.Path),
+		slog.Duration("duration", stats.Duration),
+		slog.Int("bytes_in", stats.BytesIn),
+		slog.Int("bytes_out", stats.BytesOut),
+		slog.Bool("cached", stats.Cached),
+	)
+}
+
+func (o *slogObserver) FieldFailed(field FieldInfo, err error) {
+	o.logger.LogAttrs(context.Background(), slog.LevelWarn, "markstruct field failed",
+		slog.String("path", field.Path),
+		slog.String("error", err.Error()),
+	)
+}
+
+// callStarted notifies the Observers of the converter that the fieldProcessor
+// is starting on struct v, returning the CallInfo to finish the call with.
+func (f *fieldProcessor) callStarted(v reflect.Value) CallInfo {
+	call := CallInfo{Operation: f.Operation, Type: v.Type()}
+	for _, o := range f.converter.observers {
+		o.CallStarted(call)
+	}
+	return call
+}
+
+func (f *fieldProcessor) callFinished(call CallInfo, start time.Time, changed bool, err error) {
+	result := CallResult{Duration: time.Since(start), Changed: changed, Err: err}
+	for _, o := range f.converter.observers {
+		o.CallFinished(call, result)
+	}
+}
+
+func (f *fieldProcessor) fieldRendered(stats RenderStats) {
+	for _, o := range f.converter.observers {
+		o.FieldRendered(f.field, stats)
+	}
+}
+
+func (f *fieldProcessor) fieldFailed(err error) {
+	for _, o := range f.converter.observers {
+		o.FieldFailed(f.field, err)
+	}
+}
@@ -0,0 +1,143 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+// RecordingObserver records the events it receives.
+type RecordingObserver struct {
+	Events []string
+	Stats  []RenderStats
+	Result CallResult
+}
+
+func (o *RecordingObserver) CallStarted(call CallInfo) {
+	o.Events = append(o.Events, "start "+call.Operation+" "+call.Type.Name())
+}
+
+func (o *RecordingObserver) CallFinished(call CallInfo, result CallResult) {
+	o.Events = append(o.Events, "finish "+call.Operation)
+	o.Result = result
+}
+
+func (o *RecordingObserver) FieldRendered(field FieldInfo, stats RenderStats) {
+	o.Events = append(o.Events, "rendered "+field.Path)
+	o.Stats = append(o.Stats, stats)
+}
+
+func (o *RecordingObserver) FieldFailed(field FieldInfo, err error) {
+	o.Events = append(o.Events, "failed "+field.Path+": "+err.Error())
+}
+
+func TestObserver(t *testing.T) {
+	obs := &RecordingObserver{}
+	conv := WithMarkdown(goldmark.New(), WithObserver(obs))
+
+	test := &BulkImport{
+		Title: "*title*",
+		Tags:  []string{"a"},
+		Body:  "body",
+	}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start ConvertFields BulkImport",
+		"rendered Title",
+		"rendered Tags[0]",
+		"rendered Body",
+		"finish ConvertFields",
+	}, obs.Events)
+
+	assert.Equal(t, 7, obs.Stats[0].BytesIn)
+	assert.Equal(t, len("<p><em>title</em></p>\n"), obs.Stats[0].BytesOut)
+	assert.False(t, obs.Stats[0].Cached)
+
+	assert.True(t, obs.Result.Changed)
+	assert.NoError(t, obs.Result.Err)
+}
+
+func TestObserverFieldFailed(t *testing.T) {
+	obs := &RecordingObserver{}
+	conv := WithMarkdown(
+		&FussyMarkdown{goldmark.New()},
+		WithObserver(obs),
+		WithErrorPolicy(SkipOnError),
+	)
+
+	test := &BulkImport{Title: "BOOM", Body: "body"}
+
+	_, err := conv.ValidateFields(test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start ValidateFields BulkImport",
+		"failed Title: Title: BOOM",
+		"rendered Body",
+		"finish ValidateFields",
+	}, obs.Events)
+}
+
+func TestObserverOperations(t *testing.T) {
+	obs := &RecordingObserver{}
+	conv := WithMarkdown(goldmark.New(), WithObserver(obs))
+
+	test := &BulkImport{}
+
+	conv.ConvertAllFields(test)
+	conv.ValidateAllFields(test)
+	conv.Lint(test)
+	conv.Preview(test)
+	conv.ConvertFieldsSnapshot(test)
+	conv.ConvertAllFieldsSnapshot(test)
+
+	var ops []string
+	for _, e := range obs.Events {
+		if strings.HasPrefix(e, "finish ") {
+			ops = append(ops, strings.TrimPrefix(e, "finish "))
+		}
+	}
+
+	assert.Equal(t, []string{
+		"ConvertAllFields",
+		"ValidateAllFields",
+		"Lint",
+		"Preview",
+		"ConvertFieldsSnapshot",
+		"ConvertAllFieldsSnapshot",
+	}, ops)
+}
+
+func TestSlogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	o := SlogObserver(logger)
+	call := CallInfo{Operation: "ConvertFields", Type: reflect.TypeOf(BulkImport{})}
+
+	o.CallStarted(call)
+	o.FieldRendered(FieldInfo{Path: "Body"}, RenderStats{BytesIn: 4, BytesOut: 12, Cached: true})
+	o.FieldFailed(FieldInfo{Path: "Title"}, errors.New("BOOM"))
+	o.CallFinished(call, CallResult{Err: errors.New("BOOM")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+
+	assert.Contains(t, lines[0], `level=DEBUG msg="markstruct call started" operation=ConvertFields type=markstruct.BulkImport`)
+	assert.Contains(t, lines[1], `level=DEBUG msg="markstruct field rendered" path=Body`)
+	assert.Contains(t, lines[1], `bytes_in=4 bytes_out=12 cached=true`)
+	assert.Contains(t, lines[2], `level=WARN msg="markstruct field failed" path=Title error=BOOM`)
+	assert.Contains(t, lines[3], `level=ERROR msg="markstruct call finished" operation=ConvertFields`)
+	assert.Contains(t, lines[3], `error=BOOM`)
+}
@@ -0,0 +1,244 @@
+package markstruct
+
+import (
+	"github.com/yuin/goldmark"
+)
+
+// Option configures a FieldConverter created with WithMarkdown.
+type Option func(*converter)
+
+// WithSanitizer sets a Sanitizer that is applied to the rendered HTML of
+// every field, unless the field selects another policy using the `sanitize`
+// tag option.
+func WithSanitizer(s Sanitizer) Option {
+	return func(c *converter) {
+		c.sanitizer = s
+	}
+}
+
+// WithPolicy registers a Sanitizer under name, so that fields can select it
+// using the `sanitize` tag option:
+//
+//  type Comment struct {
+//    Body string `markdown:"on,sanitize=comments"`
+//  }
+//
+//  converter := markstruct.WithMarkdown(
+//    goldmark.New(),
+//    markstruct.WithPolicy("comments", markstruct.StrictPolicy()),
+//  )
+//
+// The built-in policies PolicyStrict and PolicyUGC are always registered,
+// and may be replaced using WithPolicy.
+func WithPolicy(name string, s Sanitizer) Option {
+	return func(c *converter) {
+		c.policies[name] = s
+	}
+}
+
+// WithTrustedMarkdown sets the `goldmark.Markdown` used to render fields
+// tagged with the `trusted` option, such as admin-authored content that may
+// contain raw HTML:
+//
+//  type Page struct {
+//    Banner string `markdown:"on,trusted"`
+//    Body   string `markdown:"on"`
+//  }
+//
+//  converter := markstruct.WithMarkdown(
+//    goldmark.New(),
+//    markstruct.WithTrustedMarkdown(
+//      goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe())),
+//    ),
+//  )
+//
+// Fields without the `trusted` option keep using the converter's regular
+// `goldmark.Markdown`.  If no trusted Markdown is configured, trusted fields
+// are rendered with the regular one.  The package-level functions render
+// trusted fields with `html.WithUnsafe` enabled.
+//
+// Trusted fields are not cleaned by the Sanitizer set with WithSanitizer,
+// though they may still select a policy with the `sanitize` tag option.
+func WithTrustedMarkdown(md goldmark.Markdown) Option {
+	return func(c *converter) {
+		c.trusted = md
+	}
+}
+
+// WithDenyAction sets what happens to Markdown constructs forbidden by a
+// field's `deny` tag option.  The default, DenyError, fails both conversion
+// and validation of the field with a *DeniedNodeError naming the construct
+// and its position.  DenyStrip removes the constructs, and DenyText replaces
+// them with their plain text.
+func WithDenyAction(action DenyAction) Option {
+	return func(c *converter) {
+		c.denyAction = action
+	}
+}
+
+// WithRules sets Rules that ValidateFields and ValidateAllFields check the
+// Markdown of each field against.  If any Rule reports a problem, validation
+// returns a *LintError listing every Diagnostic.  The rules are also used by
+// Lint when it is called without rules.
+func WithRules(rules ...Rule) Option {
+	return func(c *converter) {
+		c.rules = rules
+	}
+}
+
+// WithAtomic enables atomic conversion.  Normally, fields are overwritten one
+// by one as they are rendered, so an error part way through a struct leaves
+// it holding a mix of Markdown and HTML.  With atomic conversion, every field
+// is rendered before any is written, and the struct is left exactly as it was
+// if rendering any field fails.
+func WithAtomic(enabled bool) Option {
+	return func(c *converter) {
+		c.atomic = enabled
+	}
+}
+
+// WithErrorPolicy sets how the FieldConverter handles a field that fails to
+// render: FailFast (the default) stops at the first failure, ContinueOnError
+// renders every other field and returns FieldErrors listing the failures,
+// and SkipOnError renders every other field, leaving failed fields as they
+// were without reporting an error.  The policy applies alike to struct
+// fields, slice elements and map entries.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(c *converter) {
+		c.errPolicy = policy
+	}
+}
+
+// WithPanicRecovery sets whether panics while rendering a field, such as
+// from a faulty `goldmark` extension, are recovered.  Recovery is enabled by
+// default: a panic is returned as a *FieldError holding the panic value and
+// stack trace, and handled according to the converter's ErrorPolicy.  Tests
+// may disable recovery to let panics propagate.
+func WithPanicRecovery(enabled bool) Option {
+	return func(c *converter) {
+		c.recoverPanics = enabled
+	}
+}
+
+// WithLimits sets Limits protecting the FieldConverter against hostile
+// Markdown.  A field exceeding any limit fails with a *FieldError wrapping a
+// *LimitError, handled according to the converter's ErrorPolicy:
+//
+//  converter := markstruct.WithMarkdown(
+//    goldmark.New(),
+//    markstruct.WithLimits(markstruct.Limits{
+//      MaxSourceBytes: 64 << 10,
+//      MaxDepth:       32,
+//    }),
+//  )
+func WithLimits(limits Limits) Option {
+	return func(c *converter) {
+		c.limits = limits
+	}
+}
+
+// WithBeforeRender adds a hook called with the Markdown of each field before
+// it is rendered.  The hook may replace the Markdown, or skip rendering the
+// field altogether, such as for drafts:
+//
+//  markstruct.WithBeforeRender(func(field markstruct.FieldInfo, src []byte) ([]byte, bool, error) {
+//    draft := field.Parent.FieldByName("Draft")
+//    return src, draft.IsValid() && draft.Bool(), nil
+//  })
+//
+// Hooks are called in the order they were added.
+func WithBeforeRender(fn BeforeRenderFunc) Option {
+	return func(c *converter) {
+		c.beforeRender = append(c.beforeRender, fn)
+	}
+}
+
+// WithAfterRender adds a hook called with the HTML rendered for each field,
+// which may post-process it.  Hooks are called in the order they were added,
+// after the field's HTML has been sanitized.
+func WithAfterRender(fn AfterRenderFunc) Option {
+	return func(c *converter) {
+		c.afterRender = append(c.afterRender, fn)
+	}
+}
+
+// WithObserver adds an Observer receiving events for every call to the
+// FieldConverter and every field it renders, such as the Metrics returned
+// by NewMetrics or an Observer returned by SlogObserver:
+//
+//  metrics := markstruct.NewMetrics()
+//  converter := markstruct.WithMarkdown(
+//    goldmark.New(),
+//    markstruct.WithObserver(metrics),
+//    markstruct.WithObserver(markstruct.SlogObserver(slog.Default())),
+//  )
+func WithObserver(o Observer) Option {
+	return func(c *converter) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+// WithRenderer sets the Renderer used to render fields in place of the
+// `goldmark.Markdown` given to WithMarkdown.  Sanitization, limits, hooks
+// and observers apply to the output of any Renderer.  A Renderer caching
+// HTML may report cache hits to observers with ReportCacheHit.
+//
+//  converter := markstruct.New(markstruct.WithRenderer(
+//    markstruct.RendererFunc(func(ctx context.Context, src []byte, w io.Writer, field markstruct.FieldInfo) error {
+//      _, err := w.Write(bytes.ToUpper(src))
+//      return err
+//    }),
+//  ))
+func WithRenderer(r Renderer) Option {
+	return func(c *converter) {
+		c.renderer = r
+	}
+}
+
+// WithPipeline sets the Pipeline of stages run around the rendering of each
+// field.  Postprocessors run after the field's HTML has been sanitized, and
+// before hooks added with WithAfterRender:
+//
+//  converter := markstruct.WithMarkdown(
+//    goldmark.New(),
+//    markstruct.WithPipeline(markstruct.Pipeline{
+//      Preprocessors:  []markstruct.Stage{expandShortcodes},
+//      Postprocessors: []markstruct.Stage{rewriteLinks, minify},
+//    }),
+//  )
+func WithPipeline(p Pipeline) Option {
+	return func(c *converter) {
+		c.pipeline = p
+	}
+}
+
+// WithNamedPipeline registers a Pipeline under name, for use by fields
+// selecting it with the `pipeline` tag option, such as
+// `markdown:"on,pipeline=article"`.
+func WithNamedPipeline(name string, p Pipeline) Option {
+	return func(c *converter) {
+		if c.pipelines == nil {
+			c.pipelines = map[string]Pipeline{}
+		}
+		c.pipelines[name] = p
+	}
+}
+
+// WithMinify sets whether the HTML rendered for each field is minified with
+// MinifyHTML, removing whitespace such as the newlines `goldmark` writes
+// between blocks.  Minification runs after Pipeline postprocessors, and
+// before hooks added with WithAfterRender.
+func WithMinify(enabled bool) Option {
+	return func(c *converter) {
+		c.minify = enabled
+	}
+}
+
+// WithJSONFormat sets the JSONFormat of Markdown values marshalled to JSON
+// when the FieldConverter is set with SetJSONConverter, overriding the
+// format set with SetJSONFormat.
+func WithJSONFormat(format JSONFormat) Option {
+	return func(c *converter) {
+		c.jsonFormat = &format
+	}
+}
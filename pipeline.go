@@ -0,0 +1,68 @@
+package markstruct
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Stage transforms the Markdown or HTML of a field within a Pipeline.  It
+// is given the field being converted, and returns the data to pass on to
+// the next stage.  Returning an error fails the field.
+type Stage func(field FieldInfo, data []byte) ([]byte, error)
+
+// Pipeline chains stages around the rendering of a field, so that a struct
+// is rendered, post-processed and so on in a single pass.  A field's
+// Markdown goes through each of the Preprocessors in order, is rendered and
+// sanitized, and the resulting HTML goes through each of the Postprocessors
+// in order.
+//
+// A converter's Pipeline is set with WithPipeline.  Named pipelines, set
+// with WithNamedPipeline, are selected per field with the `pipeline` tag
+// option, replacing the converter's Pipeline for that field:
+//
+//  type Post struct {
+//    Body string `markdown:"on,pipeline=article"`
+//  }
+type Pipeline struct {
+	Preprocessors  []Stage
+	Postprocessors []Stage
+}
+
+var (
+	// ErrUnknownPipeline signifies that a field's `pipeline` tag option
+	// names a pipeline that has not been registered with the converter.
+	ErrUnknownPipeline = errors.New("unknown pipeline")
+)
+
+// SanitizeStage returns a Stage cleaning HTML with s, for sanitizing the
+// output of earlier Postprocessors.
+func SanitizeStage(s Sanitizer) Stage {
+	return func(_ FieldInfo, html []byte) ([]byte, error) {
+		return s.Sanitize(html), nil
+	}
+}
+
+// pipeline returns the Pipeline for the field being converted.
+func (f *fieldProcessor) pipeline() (Pipeline, error) {
+	name := f.field.Options.Pipeline
+	if name == "" {
+		return f.converter.pipeline, nil
+	}
+
+	p, ok := f.converter.pipelines[name]
+	if !ok {
+		return Pipeline{}, fmt.Errorf("%w: %q", ErrUnknownPipeline, name)
+	}
+	return p, nil
+}
+
+// runStages passes data through stages in order.
+func (f *fieldProcessor) runStages(stages []Stage, data []byte) ([]byte, error) {
+	var err error
+	for _, stage := range stages {
+		if data, err = stage(f.field, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
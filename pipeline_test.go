@@ -0,0 +1,120 @@
+package markstruct
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func replaceStage(old string, new string) Stage {
+	return func(_ FieldInfo, data []byte) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte(old), []byte(new)), nil
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	var calls []string
+	record := func(name string) Stage {
+		return func(field FieldInfo, data []byte) ([]byte, error) {
+			calls = append(calls, name+" "+field.Path)
+			return data, nil
+		}
+	}
+
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithSanitizer(StrictPolicy()),
+		WithPipeline(Pipeline{
+			Preprocessors: []Stage{
+				record("pre"),
+				replaceStage(":smile:", "😄"),
+			},
+			Postprocessors: []Stage{
+				record("post"),
+				replaceStage("old.example.com", "example.com"),
+			},
+		}),
+		WithAfterRender(func(field FieldInfo, html []byte) ([]byte, error) {
+			calls = append(calls, "after "+field.Path)
+			return html, nil
+		}),
+	)
+
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	test := &Post{Body: "[home](https://old.example.com/) :smile: <b>hi</b>"}
+	_, err := conv.ConvertFields(test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `<p><a href="https://example.com/">home</a> 😄 hi</p>`+"\n", test.Body)
+	assert.Equal(t, []string{"pre Body", "post Body", "after Body"}, calls)
+}
+
+func TestNamedPipeline(t *testing.T) {
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithPipeline(Pipeline{
+			Postprocessors: []Stage{replaceStage("<p>", "<p class=\"default\">")},
+		}),
+		WithNamedPipeline("article", Pipeline{
+			Preprocessors:  []Stage{replaceStage("TITLE", "# Title")},
+			Postprocessors: []Stage{replaceStage("<h1>", "<h1 class=\"title\">")},
+		}),
+	)
+
+	type Post struct {
+		Summary string `markdown:"on"`
+		Body    string `markdown:"on,pipeline=article"`
+	}
+
+	test := &Post{Summary: "summary", Body: "TITLE"}
+	_, err := conv.ConvertFields(test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p class=\"default\">summary</p>\n", test.Summary)
+	assert.Equal(t, "<h1 class=\"title\">Title</h1>\n", test.Body)
+
+	type Page struct {
+		Body string `markdown:"on,pipeline=missing"`
+	}
+
+	_, err = conv.ConvertFields(&Page{Body: "body"})
+	assert.True(t, errors.Is(err, ErrUnknownPipeline))
+}
+
+func TestPipelineStageError(t *testing.T) {
+	conv := WithMarkdown(
+		goldmark.New(),
+		WithPipeline(Pipeline{
+			Postprocessors: []Stage{
+				func(_ FieldInfo, data []byte) ([]byte, error) {
+					return nil, errors.New("BOOM")
+				},
+			},
+		}),
+	)
+
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	test := &Post{Body: "body"}
+	_, err := conv.ConvertFields(test)
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Body", ferr.Path)
+	assert.Equal(t, "body", test.Body)
+}
+
+func TestSanitizeStage(t *testing.T) {
+	html, err := SanitizeStage(StrictPolicy())(FieldInfo{}, []byte(`<a href="javascript:x()">x</a>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "<a>x</a>", string(html))
+}
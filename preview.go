@@ -0,0 +1,181 @@
+package markstruct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldPreview holds the Markdown of a field and the HTML rendered from it,
+// as returned by Preview.
+type FieldPreview struct {
+	// Path locates the field within the struct, e.g. "Details.Description"
+	// or "Tags[2]".
+	Path string
+
+	// Source is the field's current Markdown.
+	Source string
+
+	// HTML is the HTML that converting the field would produce.
+	HTML string
+}
+
+// Changed reports whether converting the field would change its value.
+func (p FieldPreview) Changed() bool {
+	return p.Source != p.HTML
+}
+
+// diffContext is the number of unchanged lines shown around each change by
+// Diff.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff returns a unified diff of the lines of oldHTML and newHTML, such as
+// HTML stored by an earlier conversion and the HTML of a FieldPreview.  Diff
+// returns an empty string if there are no differences.
+func Diff(oldHTML string, newHTML string) string {
+	ops := diffLines(splitLines(oldHTML), splitLines(newHTML))
+
+	// oldPos and newPos hold the number of old and new lines preceding
+	// each op.
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for k, op := range ops {
+		oldPos[k+1], newPos[k+1] = oldPos[k], newPos[k]
+		if op.kind != '+' {
+			oldPos[k+1]++
+		}
+		if op.kind != '-' {
+			newPos[k+1]++
+		}
+	}
+
+	b := &strings.Builder{}
+
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+
+			if run == len(ops) || run-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		if b.Len() == 0 {
+			b.WriteString("--- old\n+++ new\n")
+		}
+
+		fmt.Fprintf(
+			b,
+			"@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]),
+			hunkRange(newPos[start], newPos[end]),
+		)
+
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+
+		k = end
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the lines from start (exclusive) to end (inclusive) for
+// a unified diff hunk header.
+func hunkRange(start int, end int) string {
+	count := end - start
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffLines returns the edits turning lines a into lines b, based on their
+// longest common subsequence.
+func diffLines(a []string, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,82 @@
+package markstruct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreview(t *testing.T) {
+	type Details struct {
+		Bio string `markdown:"on"`
+	}
+
+	type Profile struct {
+		Name    string
+		Summary string   `markdown:"on"`
+		Plain   string   `markdown:"on"`
+		Links   []string `markdown:"on"`
+		Details *Details
+		Notes   map[string]string `markdown:"on"`
+	}
+
+	profile := &Profile{
+		Name:    "*Al*",
+		Summary: "Hello *World*",
+		Plain:   "<p>done</p>\n",
+		Links:   []string{"[home](/)"},
+		Details: &Details{Bio: "**bold**"},
+		Notes:   map[string]string{"a": "_note_"},
+	}
+
+	previews, err := Preview(profile)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]FieldPreview{
+			{Path: "Summary", Source: "Hello *World*", HTML: "<p>Hello <em>World</em></p>\n"},
+			{Path: "Plain", Source: "<p>done</p>\n", HTML: "<!-- raw HTML omitted -->\n"},
+			{Path: "Links[0]", Source: "[home](/)", HTML: "<p><a href=\"/\">home</a></p>\n"},
+			{Path: "Details.Bio", Source: "**bold**", HTML: "<p><strong>bold</strong></p>\n"},
+			{Path: "Notes[a]", Source: "_note_", HTML: "<p><em>note</em></p>\n"},
+		},
+		previews,
+	)
+
+	assert.True(t, previews[0].Changed())
+	assert.False(t, FieldPreview{Source: "x", HTML: "x"}.Changed())
+
+	// the struct is untouched
+	assert.Equal(t, "Hello *World*", profile.Summary)
+	assert.Equal(t, "[home](/)", profile.Links[0])
+	assert.Equal(t, "**bold**", profile.Details.Bio)
+	assert.Equal(t, "_note_", profile.Notes["a"])
+
+	previews, err = Preview(*profile)
+	assert.Nil(t, previews)
+	assert.True(t, isInvalidType(err))
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "", Diff("<p>a</p>\n", "<p>a</p>\n"))
+
+	assert.Equal(
+		t,
+		"--- old\n+++ new\n@@ -1 +1 @@\n-<p>a</p>\n+<p><em>a</em></p>\n",
+		Diff("<p>a</p>\n", "<p><em>a</em></p>\n"),
+	)
+
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	updated := "1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	assert.Equal(
+		t,
+		"--- old\n+++ new\n"+
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+TWO\n 3\n 4\n 5\n"+
+			"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		Diff(old, updated),
+	)
+
+	assert.Equal(t, "--- old\n+++ new\n@@ -0,0 +1 @@\n+<p>new</p>\n", Diff("", "<p>new</p>"))
+}
@@ -0,0 +1,97 @@
+package markstruct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// registry holds the fields registered for conversion with Register, keyed
+// by struct type and then by field name.
+var registry = struct {
+	sync.RWMutex
+	types map[reflect.Type]map[string]TagOptions
+}{
+	types: map[reflect.Type]map[string]TagOptions{},
+}
+
+// Register declares which fields of a struct type to convert, for types that
+// cannot be tagged, such as those of other modules.  typ is the struct type,
+// given as a reflect.Type or as a sample value, which may be a pointer to
+// the struct.  Each field is named, optionally followed by options as they
+// would appear in a `markdown` tag after "on":
+//
+//  err := markstruct.Register(sdk.Issue{}, "Body,sanitize=strict", "Comments")
+//
+// Registered fields are converted as if they were tagged with the given
+// options, by all FieldConverters.  Fields of embedded structs are
+// registered with the embedded type.  Registering a type again replaces its
+// registered fields.  Register returns an ErrInvalidType error if typ is not
+// a struct type, or names a field it doesn't have.
+func Register(typ interface{}, fields ...string) error {
+	t, ok := typ.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(typ)
+	}
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expect struct type, got %v", ErrInvalidType, t)
+	}
+
+	options := make(map[string]TagOptions, len(fields))
+	for _, field := range fields {
+		name, opts := parseFieldSpec(field)
+		if !hasField(t, name) {
+			return fmt.Errorf("%w: %v has no field %q", ErrInvalidType, t, name)
+		}
+
+		options[name] = opts
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.types[t] = options
+
+	return nil
+}
+
+// fieldOptions returns the options of field i of struct type t, from its
+// registration with Register if it has one, otherwise from its tag.
+func fieldOptions(t reflect.Type, i int) TagOptions {
+	field := t.Field(i)
+
+	registry.RLock()
+	opts, ok := registry.types[t][field.Name]
+	registry.RUnlock()
+
+	if ok {
+		return opts
+	}
+	return parseTag(field.Tag)
+}
+
+// hasField reports whether struct type t declares a field with name, not
+// counting fields promoted from embedded structs.
+func hasField(t reflect.Type, name string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldSpec parses a field name optionally followed by tag options, such
+// as "Body,sanitize=strict", as given to Register.
+func parseFieldSpec(spec string) (string, TagOptions) {
+	name, opts := spec, ""
+	if idx := strings.IndexByte(spec, ','); idx >= 0 {
+		name, opts = spec[:idx], spec[idx+1:]
+	}
+	return strings.TrimSpace(name), parseTagValue("on," + opts)
+}
@@ -0,0 +1,86 @@
+package markstruct
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ForeignIssue stands in for a struct of another module, which cannot be
+// tagged.
+type ForeignIssue struct {
+	Title    string
+	Body     string
+	Comments []string
+	Author   string
+}
+
+type ForeignLabel struct {
+	Name        string
+	Description string
+}
+
+func TestRegister(t *testing.T) {
+	err := Register(ForeignIssue{}, "Body,sanitize=strict", "Comments")
+	assert.NoError(t, err)
+
+	type Wrapper struct {
+		Issue *ForeignIssue
+		Note  string `markdown:"on"`
+	}
+
+	test := &Wrapper{
+		Issue: &ForeignIssue{
+			Title:    "*title*",
+			Body:     "*body* <b>bold</b>",
+			Comments: []string{"*comment*"},
+			Author:   "*author*",
+		},
+		Note: "*note*",
+	}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "*title*", test.Issue.Title)
+	assert.Equal(t, "<p><em>body</em> bold</p>\n", test.Issue.Body)
+	assert.Equal(t, []string{"<p><em>comment</em></p>\n"}, test.Issue.Comments)
+	assert.Equal(t, "*author*", test.Issue.Author)
+	assert.Equal(t, "<p><em>note</em></p>\n", test.Note)
+}
+
+func TestRegisterType(t *testing.T) {
+	err := Register(reflect.TypeOf(&ForeignLabel{}), "Description")
+	assert.NoError(t, err)
+
+	test := &ForeignLabel{Name: "*name*", Description: "*description*"}
+	_, err = ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "*name*", test.Name)
+	assert.Equal(t, "<p><em>description</em></p>\n", test.Description)
+
+	// registering again replaces the registered fields
+	err = Register(ForeignLabel{}, "Name")
+	assert.NoError(t, err)
+
+	test = &ForeignLabel{Name: "*name*", Description: "*description*"}
+	_, err = ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>name</em></p>\n", test.Name)
+	assert.Equal(t, "*description*", test.Description)
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	err := Register("string", "Body")
+	assert.True(t, errors.Is(err, ErrInvalidType))
+
+	err = Register(nil, "Body")
+	assert.True(t, errors.Is(err, ErrInvalidType))
+
+	err = Register(ForeignIssue{}, "Missing")
+	assert.True(t, errors.Is(err, ErrInvalidType))
+	assert.EqualError(t, err, `invalid type: markstruct.ForeignIssue has no field "Missing"`)
+}
@@ -0,0 +1,103 @@
+package markstruct
+
+import (
+	"context"
+	"io"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Renderer renders the source of a field, usually Markdown, writing the
+// result to w.  field describes the field being rendered.  Renderers are set
+// with WithRenderer; by default a FieldConverter renders with the
+// `goldmark.Markdown` given to WithMarkdown.
+//
+// A Renderer may be an alternative Markdown engine, a deterministic fake for
+// tests, or a transformation having nothing to do with Markdown.  The `deny`
+// tag option and the MaxDepth limit inspect the `goldmark` AST, and are only
+// applied by the default Renderer.
+type Renderer interface {
+	Render(ctx context.Context, source []byte, w io.Writer, field FieldInfo) error
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(ctx context.Context, source []byte, w io.Writer, field FieldInfo) error
+
+// Render calls fn.
+func (fn RendererFunc) Render(ctx context.Context, source []byte, w io.Writer, field FieldInfo) error {
+	return fn(ctx, source, w, field)
+}
+
+type parseOptionsKey struct{}
+
+// ParseOptions returns the `goldmark` parse options given to the
+// FieldConverter method rendering a field, from the context passed to a
+// Renderer.
+func ParseOptions(ctx context.Context) []parser.ParseOption {
+	opts, _ := ctx.Value(parseOptionsKey{}).([]parser.ParseOption)
+	return opts
+}
+
+type cacheHitKey struct{}
+
+// ReportCacheHit reports, from a Renderer given ctx, that the field being
+// rendered was taken from a cache rather than rendered, so that Observers
+// receive RenderStats with Cached set.
+func ReportCacheHit(ctx context.Context) {
+	if cached, ok := ctx.Value(cacheHitKey{}).(*bool); ok {
+		*cached = true
+	}
+}
+
+// context returns the context passed to the converter's Renderer.
+func (f *fieldProcessor) context() context.Context {
+	ctx := context.WithValue(context.Background(), cacheHitKey{}, &f.cached)
+	if len(f.parseOptions) > 0 {
+		ctx = context.WithValue(ctx, parseOptionsKey{}, f.parseOptions)
+	}
+	return ctx
+}
+
+// goldmarkRenderer is the default Renderer, rendering Markdown with the
+// `goldmark.Markdown` objects of a converter.
+type goldmarkRenderer struct {
+	converter *converter
+}
+
+func (r *goldmarkRenderer) Render(ctx context.Context, source []byte, w io.Writer, field FieldInfo) error {
+	md := r.converter.markdownFor(field)
+	opts := ParseOptions(ctx)
+
+	maxDepth := r.converter.limits.MaxDepth
+	deny := field.Options.Deny
+	if len(deny) == 0 && maxDepth <= 0 {
+		return md.Convert(source, w, opts...)
+	}
+
+	// inspecting the AST requires parsing and rendering separately
+	doc := md.Parser().Parse(text.NewReader(source), opts...)
+
+	if maxDepth > 0 {
+		if err := checkDepth(doc, maxDepth); err != nil {
+			return err
+		}
+	}
+
+	if len(deny) > 0 {
+		if err := filterDeniedNodes(doc, source, deny, r.converter.denyAction); err != nil {
+			return err
+		}
+	}
+
+	return md.Renderer().Render(w, source, doc)
+}
+
+// markdownFor returns the goldmark.Markdown used to render field.
+func (c *converter) markdownFor(field FieldInfo) goldmark.Markdown {
+	if field.Options.Trusted && c.trusted != nil {
+		return c.trusted
+	}
+	return c.markdown
+}
@@ -0,0 +1,143 @@
+package markstruct
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark/parser"
+)
+
+// UpperRenderer renders a field's source in upper case, prefixed by its path.
+var UpperRenderer = RendererFunc(func(ctx context.Context, src []byte, w io.Writer, field FieldInfo) error {
+	if bytes.Contains(src, []byte("BOOM")) {
+		return errors.New("BOOM")
+	}
+	_, err := io.WriteString(w, field.Path+": "+string(bytes.ToUpper(src)))
+	return err
+})
+
+func TestWithRenderer(t *testing.T) {
+	conv := New(WithRenderer(UpperRenderer))
+
+	type Post struct {
+		Title string            `markdown:"on"`
+		Tags  []string          `markdown:"on"`
+		Meta  map[string]string `markdown:"on"`
+		Body  string
+	}
+
+	test := &Post{
+		Title: "*hello*",
+		Tags:  []string{"a"},
+		Meta:  map[string]string{"k": "v"},
+		Body:  "body",
+	}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Title: *HELLO*", test.Title)
+	assert.Equal(t, []string{"Tags[0]: A"}, test.Tags)
+	assert.Equal(t, map[string]string{"k": "Meta[k]: V"}, test.Meta)
+	assert.Equal(t, "body", test.Body)
+
+	test.Title = "BOOM"
+	_, err = conv.ConvertFields(test)
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Title", ferr.Path)
+}
+
+func TestWithRendererSanitized(t *testing.T) {
+	conv := New(
+		WithRenderer(RendererFunc(func(ctx context.Context, src []byte, w io.Writer, field FieldInfo) error {
+			_, err := w.Write(src)
+			return err
+		})),
+		WithSanitizer(StrictPolicy()),
+	)
+
+	type Comment struct {
+		Body string `markdown:"on"`
+	}
+
+	test := &Comment{Body: `<p onclick="x()">hi</p><script>alert(1)</script>`}
+	_, err := conv.ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>hi</p>", test.Body)
+}
+
+func TestRendererParseOptions(t *testing.T) {
+	var got []parser.ParseOption
+	conv := New(WithRenderer(RendererFunc(func(ctx context.Context, src []byte, w io.Writer, field FieldInfo) error {
+		got = ParseOptions(ctx)
+		return nil
+	})))
+
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	_, err := conv.ConvertFields(&Post{Body: "body"})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	opt := parser.WithContext(parser.NewContext())
+	_, err = conv.ConvertFields(&Post{Body: "body"}, opt)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestReportCacheHit(t *testing.T) {
+	rendered := map[string]string{}
+	caching := RendererFunc(func(ctx context.Context, src []byte, w io.Writer, field FieldInfo) error {
+		html, ok := rendered[string(src)]
+		if ok {
+			ReportCacheHit(ctx)
+		} else {
+			html = string(bytes.ToUpper(src))
+			rendered[string(src)] = html
+		}
+		_, err := io.WriteString(w, html)
+		return err
+	})
+
+	obs := &RecordingObserver{}
+	metrics := NewMetrics()
+	conv := New(WithRenderer(caching), WithObserver(obs), WithObserver(metrics))
+
+	type Post struct {
+		Title string `markdown:"on"`
+		Body  string `markdown:"on"`
+	}
+
+	test := &Post{Title: "same", Body: "same"}
+	_, err := conv.ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "SAME", test.Body)
+
+	assert.False(t, obs.Stats[0].Cached)
+	assert.True(t, obs.Stats[1].Cached)
+	assert.Equal(t, int64(1), metrics.Values().CacheHits)
+
+	// reporting outside of a Renderer is harmless
+	ReportCacheHit(context.Background())
+}
+
+func TestNew(t *testing.T) {
+	type Post struct {
+		Body string `markdown:"on"`
+	}
+
+	test := &Post{Body: "*body*"}
+	_, err := New().ConvertFields(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.Body)
+}
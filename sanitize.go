@@ -0,0 +1,401 @@
+package markstruct
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// Sanitizer cleans HTML produced by rendering a field's Markdown.  A
+// Sanitizer runs after rendering, and its output replaces the rendered HTML.
+type Sanitizer interface {
+	Sanitize(html []byte) []byte
+}
+
+// Policy is an allowlist Sanitizer.  Elements, attributes and URL schemes not
+// listed in the Policy are removed from rendered HTML.  The text content of a
+// removed element is kept, except for `script` and `style` elements whose
+// content is dropped along with them.  HTML comments are always removed.
+//
+// Policy is implemented in pure Go and is intended for the HTML emitted by
+// `goldmark`; it is not a general purpose HTML parser.
+type Policy struct {
+	// Elements maps the name of each allowed element to the names of the
+	// attributes allowed on it.
+	Elements map[string][]string
+
+	// URLSchemes lists the schemes allowed in URL attributes (`href`,
+	// `src` and `cite`), such as "https" or "mailto".
+	URLSchemes []string
+
+	// AllowRelativeURLs allows URL attributes whose value has no scheme.
+	AllowRelativeURLs bool
+}
+
+var _ Sanitizer = (*Policy)(nil)
+
+const (
+	// PolicyStrict is the name of the built-in StrictPolicy, usable with the
+	// `sanitize` tag option: `markdown:"on,sanitize=strict"`.
+	PolicyStrict = "strict"
+
+	// PolicyUGC is the name of the built-in UGCPolicy, usable with the
+	// `sanitize` tag option: `markdown:"on,sanitize=ugc"`.
+	PolicyUGC = "ugc"
+
+	// PolicyNone disables sanitizing of a field when used with the
+	// `sanitize` tag option: `markdown:"on,sanitize=none"`.
+	PolicyNone = "none"
+)
+
+var urlAttributes = map[string]bool{
+	"cite": true,
+	"href": true,
+	"src":  true,
+}
+
+var contentDroppingElements = []string{"script", "style"}
+
+// StrictPolicy returns a Policy suited to short user-submitted content such
+// as comments.  It allows basic text formatting, lists, quotes, code and
+// links, but no images, tables or `class` attributes.  Links must use the
+// http, https or mailto schemes.
+func StrictPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"a":          {"href", "title"},
+			"blockquote": nil,
+			"br":         nil,
+			"code":       nil,
+			"del":        nil,
+			"em":         nil,
+			"h1":         nil,
+			"h2":         nil,
+			"h3":         nil,
+			"h4":         nil,
+			"h5":         nil,
+			"h6":         nil,
+			"hr":         nil,
+			"li":         nil,
+			"ol":         {"start"},
+			"p":          nil,
+			"pre":        nil,
+			"strong":     nil,
+			"ul":         nil,
+		},
+		URLSchemes: []string{"http", "https", "mailto"},
+	}
+}
+
+// UGCPolicy returns a Policy suited to longer user-generated content.  It
+// allows everything StrictPolicy does, as well as images, tables, task list
+// checkboxes and relative URLs.
+func UGCPolicy() *Policy {
+	p := StrictPolicy()
+
+	p.Elements["img"] = []string{"src", "alt", "title"}
+	p.Elements["input"] = []string{"checked", "disabled", "type"}
+
+	for _, name := range []string{"table", "thead", "tbody", "tr"} {
+		p.Elements[name] = nil
+	}
+
+	for _, name := range []string{"th", "td"} {
+		p.Elements[name] = []string{"align"}
+	}
+
+	p.AllowRelativeURLs = true
+	return p
+}
+
+// Sanitize removes all elements, attributes and URLs not allowed by the
+// Policy from src.
+func (p *Policy) Sanitize(src []byte) []byte {
+	out := &bytes.Buffer{}
+	out.Grow(len(src))
+
+	for i := 0; i < len(src); {
+		lt := bytes.IndexByte(src[i:], '<')
+		if lt < 0 {
+			out.Write(src[i:])
+			break
+		}
+
+		out.Write(src[i : i+lt])
+		i += lt
+
+		tok, n := scanTag(src[i:])
+		if n == 0 {
+			// a lone '<' that doesn't start a tag
+			out.WriteString("&lt;")
+			i++
+			continue
+		}
+		i += n
+
+		if tok.comment {
+			continue
+		}
+
+		if !p.allowsElement(tok.name) {
+			if !tok.closing && isContentDropping(tok.name) {
+				i += skipElementContent(src[i:], tok.name)
+			}
+			continue
+		}
+
+		p.writeTag(out, tok)
+	}
+
+	return out.Bytes()
+}
+
+func (p *Policy) allowsElement(name string) bool {
+	_, ok := p.Elements[name]
+	return ok
+}
+
+func (p *Policy) allowsAttribute(element string, name string) bool {
+	for _, allowed := range p.Elements[element] {
+		if strings.EqualFold(allowed, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) allowsURL(rawurl string) bool {
+	u := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, rawurl)
+
+	end := strings.IndexAny(u, "/?#")
+	if end < 0 {
+		end = len(u)
+	}
+
+	colon := strings.IndexByte(u[:end], ':')
+	if colon < 0 {
+		return p.AllowRelativeURLs
+	}
+
+	scheme := u[:colon]
+	for _, allowed := range p.URLSchemes {
+		if strings.EqualFold(allowed, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) writeTag(out *bytes.Buffer, tok tagToken) {
+	out.WriteByte('<')
+	if tok.closing {
+		out.WriteByte('/')
+		out.WriteString(tok.name)
+		out.WriteByte('>')
+		return
+	}
+
+	out.WriteString(tok.name)
+
+	for _, attr := range tok.attrs {
+		if !p.allowsAttribute(tok.name, attr.name) {
+			continue
+		}
+
+		if urlAttributes[attr.name] && !p.allowsURL(attr.value) {
+			continue
+		}
+
+		out.WriteByte(' ')
+		out.WriteString(attr.name)
+		out.WriteString(`="`)
+		out.WriteString(html.EscapeString(attr.value))
+		out.WriteByte('"')
+	}
+
+	if tok.selfClosing {
+		out.WriteString(" /")
+	}
+	out.WriteByte('>')
+}
+
+type tagAttribute struct {
+	name  string
+	value string
+}
+
+type tagToken struct {
+	name        string
+	attrs       []tagAttribute
+	closing     bool
+	selfClosing bool
+	comment     bool
+}
+
+// scanTag scans the tag, comment or declaration at the start of b, which
+// must begin with '<'.  scanTag returns the token and the number of bytes it
+// spans, or a zero length if b does not start with a tag.
+func scanTag(b []byte) (tagToken, int) {
+	if bytes.HasPrefix(b, []byte("<!--")) {
+		end := bytes.Index(b[4:], []byte("-->"))
+		if end < 0 {
+			return tagToken{comment: true}, len(b)
+		}
+		return tagToken{comment: true}, 4 + end + 3
+	}
+
+	if len(b) > 1 && (b[1] == '!' || b[1] == '?') {
+		end := bytes.IndexByte(b, '>')
+		if end < 0 {
+			return tagToken{comment: true}, len(b)
+		}
+		return tagToken{comment: true}, end + 1
+	}
+
+	tok := tagToken{}
+	i := 1
+	if i < len(b) && b[i] == '/' {
+		tok.closing = true
+		i++
+	}
+
+	start := i
+	for i < len(b) && isTagNameByte(b[i], i == start) {
+		i++
+	}
+	if i == start {
+		return tagToken{}, 0
+	}
+	tok.name = strings.ToLower(string(b[start:i]))
+
+	for i < len(b) {
+		for i < len(b) && isSpace(b[i]) {
+			i++
+		}
+
+		if i >= len(b) {
+			break
+		}
+
+		switch b[i] {
+		case '>':
+			return tok, i + 1
+		case '/':
+			tok.selfClosing = true
+			i++
+			continue
+		}
+
+		attr, n := scanAttribute(b[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+
+		tok.selfClosing = false
+		tok.attrs = append(tok.attrs, attr)
+		i += n
+	}
+
+	return tok, len(b)
+}
+
+func scanAttribute(b []byte) (tagAttribute, int) {
+	i := 0
+	for i < len(b) && !isSpace(b[i]) && b[i] != '=' && b[i] != '>' && b[i] != '/' {
+		i++
+	}
+	if i == 0 {
+		return tagAttribute{}, 0
+	}
+
+	attr := tagAttribute{name: strings.ToLower(string(b[:i]))}
+
+	j := i
+	for j < len(b) && isSpace(b[j]) {
+		j++
+	}
+	if j >= len(b) || b[j] != '=' {
+		return attr, i
+	}
+
+	j++
+	for j < len(b) && isSpace(b[j]) {
+		j++
+	}
+	if j >= len(b) {
+		return attr, j
+	}
+
+	var raw []byte
+	switch quote := b[j]; quote {
+	case '"', '\'':
+		end := bytes.IndexByte(b[j+1:], quote)
+		if end < 0 {
+			raw = b[j+1:]
+			j = len(b)
+		} else {
+			raw = b[j+1 : j+1+end]
+			j += end + 2
+		}
+	default:
+		start := j
+		for j < len(b) && !isSpace(b[j]) && b[j] != '>' {
+			j++
+		}
+		raw = b[start:j]
+	}
+
+	attr.value = html.UnescapeString(string(raw))
+	return attr, j
+}
+
+// skipElementContent returns the number of bytes in b up to and including
+// the closing tag of the named element, or len(b) if there isn't one.
+func skipElementContent(b []byte, name string) int {
+	closing := []byte("</" + name)
+	lower := bytes.ToLower(b)
+
+	for i := 0; ; {
+		idx := bytes.Index(lower[i:], closing)
+		if idx < 0 {
+			return len(b)
+		}
+		i += idx
+
+		tok, n := scanTag(b[i:])
+		if n > 0 && tok.closing && tok.name == name {
+			return i + n
+		}
+		i += len(closing)
+	}
+}
+
+func isContentDropping(name string) bool {
+	for _, element := range contentDroppingElements {
+		if element == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isTagNameByte(c byte, first bool) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+		return true
+	}
+	return !first && ('0' <= c && c <= '9' || c == '-')
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
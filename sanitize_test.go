@@ -0,0 +1,160 @@
+package markstruct
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+func TestStrictPolicySanitize(t *testing.T) {
+	policy := StrictPolicy()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"<p>Hello <em>World</em></p>\n",
+			"<p>Hello <em>World</em></p>\n",
+		},
+		{
+			`<p><img src="cat.png" alt="cat" /> cat</p>`,
+			"<p> cat</p>",
+		},
+		{
+			`<p class="lead">text</p>`,
+			"<p>text</p>",
+		},
+		{
+			`<a href="https://example.com" class="x" title="Ex">link</a>`,
+			`<a href="https://example.com" title="Ex">link</a>`,
+		},
+		{
+			`<a href="javascript:alert(1)">link</a>`,
+			"<a>link</a>",
+		},
+		{
+			`<a href="java&#x09;script&#58;alert(1)">link</a>`,
+			"<a>link</a>",
+		},
+		{
+			`<a href="/relative">link</a>`,
+			"<a>link</a>",
+		},
+		{
+			`<a href="mailto:me@example.com">mail</a>`,
+			`<a href="mailto:me@example.com">mail</a>`,
+		},
+		{
+			"<p>before<script>alert('<p>')</script>after</p>",
+			"<p>beforeafter</p>",
+		},
+		{
+			"<p>a<!-- comment -->b</p>",
+			"<p>ab</p>",
+		},
+		{
+			"<p>a < b</p>",
+			"<p>a &lt; b</p>",
+		},
+		{
+			"line<br />break<HR>",
+			"line<br />break<hr>",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, string(policy.Sanitize([]byte(test.input))), test.input)
+	}
+}
+
+func TestUGCPolicySanitize(t *testing.T) {
+	policy := UGCPolicy()
+
+	assert.Equal(
+		t,
+		`<p><img src="cat.png" alt="cat" /></p>`,
+		string(policy.Sanitize([]byte(`<p><img src="cat.png" alt="cat" class="x" /></p>`))),
+	)
+
+	assert.Equal(
+		t,
+		`<a href="/relative">link</a>`,
+		string(policy.Sanitize([]byte(`<a href="/relative">link</a>`))),
+	)
+
+	assert.Equal(
+		t,
+		`<img alt="x" />`,
+		string(policy.Sanitize([]byte(`<img src="data:image/png;base64,AAAA" alt="x" />`))),
+	)
+}
+
+func TestConvertFieldsSanitizeTag(t *testing.T) {
+	type Comment struct {
+		Author string `markdown:"on"`
+		Body   string `markdown:"on,sanitize=strict"`
+		Notes  string `markdown:"on,sanitize=ugc"`
+	}
+
+	comment := &Comment{
+		Author: "![avatar](me.png)",
+		Body:   "![cat](cat.png) [site](javascript:alert(1))",
+		Notes:  "![cat](cat.png)",
+	}
+
+	changed, err := ConvertFields(comment)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><img src=\"me.png\" alt=\"avatar\"></p>\n", comment.Author)
+	assert.Equal(t, "<p> <a>site</a></p>\n", comment.Body)
+	assert.Equal(t, "<p><img src=\"cat.png\" alt=\"cat\"></p>\n", comment.Notes)
+}
+
+func TestWithSanitizer(t *testing.T) {
+	type Page struct {
+		Body  string `markdown:"on"`
+		Image string `markdown:"on,sanitize=none"`
+		Plain string `markdown:"on,sanitize=plain"`
+	}
+
+	plain := &Policy{}
+
+	conv := WithMarkdown(
+		goldmark.New(goldmark.WithRendererOptions(html.WithXHTML())),
+		WithSanitizer(StrictPolicy()),
+		WithPolicy("plain", plain),
+	)
+
+	page := &Page{
+		Body:  "![cat](cat.png) **bold**",
+		Image: "![cat](cat.png)",
+		Plain: "**bold**",
+	}
+
+	changed, err := conv.ConvertFields(page)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p> <strong>bold</strong></p>\n", page.Body)
+	assert.Equal(t, "<p><img src=\"cat.png\" alt=\"cat\" /></p>\n", page.Image)
+	assert.Equal(t, "bold\n", page.Plain)
+}
+
+func TestUnknownPolicy(t *testing.T) {
+	type Page struct {
+		Body string `markdown:"on,sanitize=missing"`
+	}
+
+	page := &Page{Body: "*text*"}
+
+	changed, err := ConvertFields(page)
+	assert.False(t, changed)
+	assert.True(t, errors.Is(err, ErrUnknownPolicy))
+	assert.Equal(t, "*text*", page.Body)
+}
@@ -0,0 +1,138 @@
+package markstruct
+
+import (
+	"context"
+	"io"
+	"reflect"
+)
+
+// MarkdownFielder is implemented by types deciding at runtime which of their
+// fields hold Markdown, such as a block whose content is only Markdown for
+// some kinds of block.  MarkdownFields returns the names of the fields to
+// convert, each optionally followed by options as they would appear in a
+// `markdown` tag after "on":
+//
+//  func (b *Block) MarkdownFields() []string {
+//    if b.Kind == "text" {
+//      return []string{"Content,sanitize=strict"}
+//    }
+//    return nil
+//  }
+//
+// The fields returned replace any `markdown` tags of the type.  Nested
+// structs are still converted according to their own fields.
+type MarkdownFielder interface {
+	MarkdownFields() []string
+}
+
+// MarkdownConverter is implemented by types converting their own Markdown.
+// ConvertMarkdown renders each of its Markdown values with r, and reports
+// whether it changed anything.  The FieldInfo passed to r describes the
+// value rendered; its Path is relative to the type, and its Options apply
+// as tag options would.  Rendering with r applies the FieldConverter's
+// sanitization, pipelines, hooks, limits and observers.  Errors returned by
+// r are *FieldErrors locating the value, which ConvertMarkdown should
+// return as is.  r also fails for values skipped by a BeforeRenderFunc, in
+// which case the whole value is left unchanged.
+//
+// ConvertMarkdown is called on a shallow copy of the value, which replaces
+// the original if it succeeds.  Slices, maps and pointers held by the value
+// are shared with the original, so ConvertMarkdown must replace them rather
+// than modify their contents in place: contents modified in place are not
+// restored by atomic conversion or snapshots, even if ConvertMarkdown fails.
+// MarkdownConverters are not called when only validating or previewing,
+// and are walked like other structs by Walk.
+type MarkdownConverter interface {
+	ConvertMarkdown(r Renderer) (bool, error)
+}
+
+// asInterface returns struct v, or a pointer to it if v is addressable, as
+// an interface{} for checking which interfaces it implements.
+func asInterface(v reflect.Value) (interface{}, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	if v.CanAddr() {
+		return v.Addr().Interface(), true
+	}
+	return v.Interface(), true
+}
+
+// markdownFields returns the options of the fields of struct v listed by its
+// MarkdownFields method, or nil if v is not a MarkdownFielder.
+func markdownFields(v reflect.Value) map[string]TagOptions {
+	i, ok := asInterface(v)
+	if !ok {
+		return nil
+	}
+
+	fielder, ok := i.(MarkdownFielder)
+	if !ok {
+		return nil
+	}
+
+	fields := map[string]TagOptions{}
+	for _, spec := range fielder.MarkdownFields() {
+		name, opts := parseFieldSpec(spec)
+		fields[name] = opts
+	}
+	return fields
+}
+
+// convertSelf converts struct v if it is a MarkdownConverter, reporting
+// whether it is one.  When walking fields with a Visitor other than Markdown
+// conversion, v is never treated as a MarkdownConverter.
+func (f *fieldProcessor) convertSelf(v reflect.Value) (bool, bool, error) {
+	if !v.CanInterface() || !f.rendersMarkdown() {
+		return false, false, nil
+	}
+
+	cp := reflect.New(v.Type())
+	mc, ok := cp.Interface().(MarkdownConverter)
+	if !ok {
+		return false, false, nil
+	}
+
+	if f.ValidateOnly || !v.CanSet() {
+		return false, true, nil
+	}
+
+	cp.Elem().Set(v)
+	old := reflect.New(v.Type()).Elem()
+	old.Set(v)
+
+	changed, err := mc.ConvertMarkdown(f.selfRenderer())
+	if err != nil {
+		return false, true, f.handleError(err)
+	}
+
+	if changed {
+		f.write(fieldChange{
+			target: v,
+			old:    old,
+			new:    cp.Elem(),
+		})
+	}
+
+	return changed, true, nil
+}
+
+// selfRenderer returns the Renderer passed to a MarkdownConverter, rendering
+// values as fields of the struct being converted.
+func (f *fieldProcessor) selfRenderer() Renderer {
+	parent := f.field
+
+	return RendererFunc(func(_ context.Context, source []byte, w io.Writer, field FieldInfo) error {
+		f.field = field
+		f.field.Path = joinPath(parent.Path, field.Path)
+		defer func() { f.field = parent }()
+
+		rendered, err := f.render(source)
+		if err != nil {
+			return f.fieldError(err)
+		}
+
+		_, err = w.Write(rendered)
+		return err
+	})
+}
@@ -0,0 +1,145 @@
+package markstruct
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+type Block struct {
+	Kind    string
+	Content string
+	Caption string `markdown:"on"`
+}
+
+func (b *Block) MarkdownFields() []string {
+	if b.Kind == "text" {
+		return []string{"Content,sanitize=strict"}
+	}
+	return nil
+}
+
+type RichText struct {
+	Source string
+	HTML   string
+}
+
+func (r *RichText) ConvertMarkdown(renderer Renderer) (bool, error) {
+	b := &bytes.Buffer{}
+	err := renderer.Render(context.Background(), []byte(r.Source), b, FieldInfo{Path: "Source"})
+	if err != nil {
+		return false, err
+	}
+
+	changed := r.HTML != b.String()
+	r.HTML = b.String()
+	return changed, nil
+}
+
+func TestMarkdownFielder(t *testing.T) {
+	type Page struct {
+		Blocks []Block `markdown:"on"`
+	}
+
+	test := &Page{
+		Blocks: []Block{
+			{Kind: "text", Content: "*text* <b>x</b>", Caption: "*caption*"},
+			{Kind: "code", Content: "*code*", Caption: "*caption*"},
+		},
+	}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><em>text</em> x</p>\n", test.Blocks[0].Content)
+	assert.Equal(t, "*caption*", test.Blocks[0].Caption)
+	assert.Equal(t, "*code*", test.Blocks[1].Content)
+	assert.Equal(t, "*caption*", test.Blocks[1].Caption)
+}
+
+func TestMarkdownConverter(t *testing.T) {
+	type Post struct {
+		Title string `markdown:"on"`
+		Body  RichText
+	}
+
+	var paths []string
+	conv := WithMarkdown(goldmark.New(), WithAfterRender(func(field FieldInfo, html []byte) ([]byte, error) {
+		paths = append(paths, field.Path)
+		return html, nil
+	}))
+
+	test := &Post{Title: "*title*", Body: RichText{Source: "*body*"}}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><em>title</em></p>\n", test.Title)
+	assert.Equal(t, RichText{Source: "*body*", HTML: "<p><em>body</em></p>\n"}, test.Body)
+	assert.Equal(t, []string{"Title", "Body.Source"}, paths)
+
+	changed, err = conv.ConvertFields(&test.Body)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+}
+
+func TestMarkdownConverterValidateAndSnapshot(t *testing.T) {
+	test := &RichText{Source: "*body*"}
+
+	changed, err := ValidateFields(test)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "", test.HTML)
+
+	snap, err := ConvertFieldsSnapshot(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.HTML)
+	assert.Equal(t, []string{""}, snap.Paths())
+
+	snap.Restore()
+	assert.Equal(t, &RichText{Source: "*body*"}, test)
+}
+
+func TestMarkdownConverterWalk(t *testing.T) {
+	type Post struct {
+		Title string
+		Body  RichText
+	}
+
+	test := &Post{Title: "  *title*  ", Body: RichText{Source: "  *a*  "}}
+
+	changed, err := TransformAllFields(test, func(field FieldInfo, value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "*title*", test.Title)
+	assert.Equal(t, RichText{Source: "*a*"}, test.Body)
+}
+
+func TestMarkdownConverterError(t *testing.T) {
+	type Post struct {
+		Title string `markdown:"on"`
+		Body  RichText
+	}
+
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithAtomic(true))
+
+	test := &Post{Title: "*title*", Body: RichText{Source: "BOOM"}}
+	_, err := conv.ConvertFields(test)
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Body.Source", ferr.Path)
+	assert.Equal(t, "*title*", test.Title)
+	assert.Equal(t, "", test.Body.HTML)
+}
@@ -0,0 +1,54 @@
+package markstruct
+
+// Snapshot records the original values of the fields changed by a
+// conversion, as returned by ConvertFieldsSnapshot.  Each location is
+// recorded individually, be it a string field, the target of a pointer, a
+// slice element or a map entry.
+type Snapshot struct {
+	changes []fieldChange
+}
+
+func newSnapshot(changes []fieldChange) *Snapshot {
+	snap := &Snapshot{}
+	for _, change := range changes {
+		if change.changed() {
+			snap.changes = append(snap.changes, change)
+		}
+	}
+	return snap
+}
+
+// Changed reports whether the conversion changed any field.
+func (s *Snapshot) Changed() bool {
+	return len(s.changes) > 0
+}
+
+// Paths returns the paths of the changed fields, e.g. "Details.Description"
+// or "Tags[2]", in the order they were converted.
+func (s *Snapshot) Paths() []string {
+	paths := make([]string, len(s.changes))
+	for i, change := range s.changes {
+		paths[i] = change.path
+	}
+	return paths
+}
+
+// Original returns the original value recorded for the field at path, and
+// whether the field was changed.
+func (s *Snapshot) Original(path string) (interface{}, bool) {
+	for _, change := range s.changes {
+		if change.path == path {
+			return change.old.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// Restore puts back the original value of every changed field.  Values
+// assigned to those fields since the conversion are overwritten.
+func (s *Snapshot) Restore() {
+	for i := len(s.changes) - 1; i >= 0; i-- {
+		change := s.changes[i]
+		change.apply(change.old)
+	}
+}
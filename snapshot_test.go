@@ -0,0 +1,100 @@
+package markstruct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	type Details struct {
+		Bio string `markdown:"on"`
+	}
+
+	type Profile struct {
+		Name    string
+		Summary string   `markdown:"on"`
+		Tagline *string  `markdown:"on"`
+		Links   []string `markdown:"on"`
+		Details Details
+		Notes   map[string]string `markdown:"on"`
+	}
+
+	tagline := "_hi_"
+
+	profile := &Profile{
+		Name:    "*Al*",
+		Summary: "Hello *World*",
+		Tagline: &tagline,
+		Links:   []string{"[home](/)", "[about](/about)"},
+		Details: Details{Bio: "**bold**"},
+		Notes:   map[string]string{"a": "_note_"},
+	}
+
+	original := *profile
+	original.Links = []string{"[home](/)", "[about](/about)"}
+	original.Notes = map[string]string{"a": "_note_"}
+
+	snap, err := ConvertFieldsSnapshot(profile)
+	assert.NoError(t, err)
+	assert.True(t, snap.Changed())
+
+	assert.Equal(
+		t,
+		[]string{"Summary", "Tagline", "Links[0]", "Links[1]", "Details.Bio", "Notes[a]"},
+		snap.Paths(),
+	)
+
+	assert.Equal(t, "<p>Hello <em>World</em></p>\n", profile.Summary)
+	assert.Equal(t, "<p><em>hi</em></p>\n", tagline)
+	assert.Equal(t, "<p><em>note</em></p>\n", profile.Notes["a"])
+
+	value, ok := snap.Original("Details.Bio")
+	assert.True(t, ok)
+	assert.Equal(t, "**bold**", value)
+
+	value, ok = snap.Original("Name")
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	snap.Restore()
+
+	assert.Equal(t, original.Summary, profile.Summary)
+	assert.Equal(t, "_hi_", tagline)
+	assert.Equal(t, original.Links, profile.Links)
+	assert.Equal(t, original.Details, profile.Details)
+	assert.Equal(t, original.Notes, profile.Notes)
+}
+
+func TestSnapshotUnchanged(t *testing.T) {
+	conv := WithMarkdown(goldmark.New())
+
+	snap, err := conv.ConvertAllFieldsSnapshot(&MyStruct{})
+	assert.NoError(t, err)
+	assert.False(t, snap.Changed())
+	assert.Empty(t, snap.Paths())
+
+	snap, err = ConvertFieldsSnapshot(nil)
+	assert.NoError(t, err)
+	assert.False(t, snap.Changed())
+	snap.Restore()
+}
+
+func TestSnapshotWithError(t *testing.T) {
+	type Test struct {
+		First  string `markdown:"on"`
+		Second string `markdown:"on,sanitize=missing"`
+	}
+
+	test := &Test{First: "*one*", Second: "*two*"}
+
+	snap, err := ConvertFieldsSnapshot(test)
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+	assert.Equal(t, []string{"First"}, snap.Paths())
+	assert.Equal(t, "<p><em>one</em></p>\n", test.First)
+
+	snap.Restore()
+	assert.Equal(t, "*one*", test.First)
+}
@@ -0,0 +1,124 @@
+package markstruct
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// TypeConverterFunc converts a value of a type registered with
+// RegisterTypeConverter, such as `sql.NullString` or a struct holding both
+// Markdown and its HTML.  v is a settable shallow copy of the value, which
+// replaces the original if TypeConverterFunc reports it changed.  Markdown
+// is rendered with r as by a MarkdownConverter, and ctx holds the parse
+// options of the call (see ParseOptions).
+//
+// Slices, maps and pointers held by v are shared with the original value,
+// so TypeConverterFunc must replace them rather than modify their contents
+// in place: contents modified in place are not restored by atomic
+// conversion or snapshots, even if TypeConverterFunc fails.
+type TypeConverterFunc func(ctx context.Context, v reflect.Value, r Renderer) (bool, error)
+
+// typeConverters holds the converters registered with
+// RegisterTypeConverter, keyed by type.
+var typeConverters = struct {
+	sync.RWMutex
+	types map[reflect.Type]TypeConverterFunc
+}{
+	types: map[reflect.Type]TypeConverterFunc{},
+}
+
+// RegisterTypeConverter registers fn to convert every value of type t found
+// by a FieldConverter, in place of its usual handling: struct fields,
+// pointer targets, slice elements and map values alike, as well as the
+// struct passed to the FieldConverter if of type t.  Struct fields of type
+// t, or pointer to t, are only converted when tagged, or by
+// ConvertAllFields.
+//
+//  markstruct.RegisterTypeConverter(reflect.TypeOf(sql.NullString{}),
+//    func(ctx context.Context, v reflect.Value, r markstruct.Renderer) (bool, error) {
+//      s := v.Addr().Interface().(*sql.NullString)
+//      if !s.Valid {
+//        return false, nil
+//      }
+//      b := &bytes.Buffer{}
+//      if err := r.Render(ctx, []byte(s.String), b, markstruct.FieldInfo{}); err != nil {
+//        return false, err
+//      }
+//      changed := s.String != b.String()
+//      s.String = b.String()
+//      return changed, nil
+//    })
+//
+// Type converters are not called when only validating or previewing, nor by
+// Walk, which handles values of type t like any other.  Registering a nil fn
+// removes the converter for t.
+func RegisterTypeConverter(t reflect.Type, fn TypeConverterFunc) {
+	typeConverters.Lock()
+	defer typeConverters.Unlock()
+
+	if fn == nil {
+		delete(typeConverters.types, t)
+		return
+	}
+	typeConverters.types[t] = fn
+}
+
+// typeConverter returns the converter registered for t, or nil.
+func typeConverter(t reflect.Type) TypeConverterFunc {
+	typeConverters.RLock()
+	defer typeConverters.RUnlock()
+
+	return typeConverters.types[t]
+}
+
+// customConverter returns the converter registered for t, or nil.  When
+// walking fields with a Visitor other than Markdown conversion, type
+// converters are ignored, and values of type t are walked like any other.
+func (f *fieldProcessor) customConverter(t reflect.Type) TypeConverterFunc {
+	if !f.rendersMarkdown() {
+		return nil
+	}
+	return typeConverter(t)
+}
+
+// hasTypeConverter reports whether a converter applies to t, or to the type
+// t points to.
+func (f *fieldProcessor) hasTypeConverter(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return f.customConverter(t) != nil
+}
+
+// convertCustom converts value with fn, a registered type converter.  fn is
+// called on a copy of value, which is written in place of value if changed.
+// value is the element of map target for key, if key is valid, otherwise
+// target itself.
+func (f *fieldProcessor) convertCustom(fn TypeConverterFunc, value reflect.Value, target reflect.Value, key reflect.Value) (bool, error) {
+	if f.ValidateOnly || !target.CanSet() {
+		return false, nil
+	}
+
+	old := reflect.New(value.Type()).Elem()
+	old.Set(value)
+
+	cp := reflect.New(value.Type()).Elem()
+	cp.Set(value)
+
+	changed, err := fn(f.context(), cp, f.selfRenderer())
+	if err != nil {
+		return false, f.handleError(err)
+	}
+
+	if changed {
+		f.write(fieldChange{
+			target: target,
+			key:    key,
+			old:    old,
+			new:    cp,
+		})
+	}
+
+	return changed, nil
+}
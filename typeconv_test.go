@@ -0,0 +1,135 @@
+package markstruct
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func convertNullString(ctx context.Context, v reflect.Value, r Renderer) (bool, error) {
+	s := v.Addr().Interface().(*sql.NullString)
+	if !s.Valid {
+		return false, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := r.Render(ctx, []byte(s.String), b, FieldInfo{}); err != nil {
+		return false, err
+	}
+
+	changed := s.String != b.String()
+	s.String = b.String()
+	return changed, nil
+}
+
+func registerNullString(t *testing.T) {
+	typ := reflect.TypeOf(sql.NullString{})
+	RegisterTypeConverter(typ, convertNullString)
+	t.Cleanup(func() { RegisterTypeConverter(typ, nil) })
+}
+
+func TestRegisterTypeConverter(t *testing.T) {
+	registerNullString(t)
+
+	type Post struct {
+		Title    sql.NullString            `markdown:"on"`
+		Summary  *sql.NullString           `markdown:"on"`
+		Notes    []sql.NullString          `markdown:"on"`
+		Meta     map[string]sql.NullString `markdown:"on"`
+		Missing  sql.NullString            `markdown:"on"`
+		Untagged sql.NullString
+	}
+
+	test := &Post{
+		Title:    sql.NullString{String: "*title*", Valid: true},
+		Summary:  &sql.NullString{String: "*summary*", Valid: true},
+		Notes:    []sql.NullString{{String: "*note*", Valid: true}},
+		Meta:     map[string]sql.NullString{"k": {String: "*meta*", Valid: true}},
+		Missing:  sql.NullString{String: "*missing*"},
+		Untagged: sql.NullString{String: "*untagged*", Valid: true},
+	}
+
+	changed, err := ConvertFields(test)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql.NullString{String: "<p><em>title</em></p>\n", Valid: true}, test.Title)
+	assert.Equal(t, &sql.NullString{String: "<p><em>summary</em></p>\n", Valid: true}, test.Summary)
+	assert.Equal(t, []sql.NullString{{String: "<p><em>note</em></p>\n", Valid: true}}, test.Notes)
+	assert.Equal(t, map[string]sql.NullString{"k": {String: "<p><em>meta</em></p>\n", Valid: true}}, test.Meta)
+	assert.Equal(t, sql.NullString{String: "*missing*"}, test.Missing)
+	assert.Equal(t, sql.NullString{String: "*untagged*", Valid: true}, test.Untagged)
+}
+
+func TestRegisterTypeConverterTopLevel(t *testing.T) {
+	registerNullString(t)
+
+	test := &sql.NullString{String: "*body*", Valid: true}
+
+	changed, err := ValidateFields(test)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, "*body*", test.String)
+
+	snap, err := ConvertFieldsSnapshot(test)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>body</em></p>\n", test.String)
+
+	snap.Restore()
+	assert.Equal(t, &sql.NullString{String: "*body*", Valid: true}, test)
+}
+
+func TestRegisterTypeConverterWalk(t *testing.T) {
+	registerNullString(t)
+
+	type Post struct {
+		Note sql.NullString `markdown:"on"`
+	}
+
+	test := &Post{Note: sql.NullString{String: "  *a*  ", Valid: true}}
+
+	changed, err := TransformFields(test, func(field FieldInfo, value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullString{String: "*a*", Valid: true}, test.Note)
+}
+
+func TestRegisterTypeConverterErrors(t *testing.T) {
+	registerNullString(t)
+
+	type Post struct {
+		Title sql.NullString            `markdown:"on"`
+		Meta  map[string]sql.NullString `markdown:"on"`
+	}
+
+	conv := WithMarkdown(&FussyMarkdown{goldmark.New()}, WithErrorPolicy(ContinueOnError))
+
+	test := &Post{
+		Title: sql.NullString{String: "*title*", Valid: true},
+		Meta: map[string]sql.NullString{
+			"a": {String: "BOOM", Valid: true},
+			"b": {String: "*b*", Valid: true},
+		},
+	}
+
+	changed, err := conv.ConvertFields(test)
+	assert.True(t, changed)
+	assert.EqualError(t, err, "Meta[a]: BOOM")
+
+	var ferrs FieldErrors
+	assert.True(t, errors.As(err, &ferrs))
+
+	assert.Equal(t, "<p><em>title</em></p>\n", test.Title.String)
+	assert.Equal(t, "BOOM", test.Meta["a"].String)
+	assert.Equal(t, "<p><em>b</em></p>\n", test.Meta["b"].String)
+}
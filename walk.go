@@ -0,0 +1,91 @@
+package markstruct
+
+// Visitor transforms the string values found by Walk.  Visit is called with
+// a description of the field holding each value, and returns its new value.
+// Markdown conversion is itself a Visitor rendering each value as HTML.
+type Visitor interface {
+	Visit(field FieldInfo, value string) (string, error)
+}
+
+// VisitorFunc adapts a function to a Visitor.
+type VisitorFunc func(field FieldInfo, value string) (string, error)
+
+// Visit calls fn.
+func (fn VisitorFunc) Visit(field FieldInfo, value string) (string, error) {
+	return fn(field, value)
+}
+
+// Walk accepts a pointer to a struct, and replaces the value of every tagged
+// field of relevant type with the value returned for it by v, the same way
+// ConvertFields replaces them with HTML.  Fields are found through pointers,
+// slices, maps and nested structs, and are located by the Path of the
+// FieldInfo passed to v.  Walk returns whether any value was changed, as
+// well as any error returned by v, wrapped in a *FieldError.
+//
+// Walk can be used for other transformations of string fields, such as
+// trimming or masking profanity.  MarkdownConverters and registered
+// type converters are not called by Walk; their values are visited like
+// any other.
+func Walk(s interface{}, v Visitor) (bool, error) {
+	return defaultConverter.Walk(s, v)
+}
+
+// WalkAllFields is like Walk, but visits all string fields regardless of
+// whether they are tagged.
+func WalkAllFields(s interface{}, v Visitor) (bool, error) {
+	return defaultConverter.WalkAllFields(s, v)
+}
+
+// TransformFields calls Walk with fn as the Visitor:
+//
+//  changed, err := markstruct.TransformFields(post, func(field markstruct.FieldInfo, value string) (string, error) {
+//    return strings.TrimSpace(value), nil
+//  })
+func TransformFields(s interface{}, fn func(field FieldInfo, value string) (string, error)) (bool, error) {
+	return defaultConverter.Walk(s, VisitorFunc(fn))
+}
+
+// TransformAllFields calls WalkAllFields with fn as the Visitor.
+func TransformAllFields(s interface{}, fn func(field FieldInfo, value string) (string, error)) (bool, error) {
+	return defaultConverter.WalkAllFields(s, VisitorFunc(fn))
+}
+
+func (c *converter) Walk(s interface{}, v Visitor) (bool, error) {
+	return c.walk(s, "Walk", false, v)
+}
+
+func (c *converter) WalkAllFields(s interface{}, v Visitor) (bool, error) {
+	return c.walk(s, "WalkAllFields", true, v)
+}
+
+func (c *converter) walk(s interface{}, op string, allFields bool, v Visitor) (bool, error) {
+	elem, err := structElem(s)
+	if err != nil || !elem.IsValid() {
+		return false, err
+	}
+
+	fieldproc := makeFieldProcessor(c)
+	fieldproc.Operation = op
+	fieldproc.ConvertAllFields = allFields
+	fieldproc.Atomic = c.atomic
+	fieldproc.ErrorPolicy = c.errPolicy
+	fieldproc.visitor = v
+
+	return fieldproc.run(elem)
+}
+
+// markdownVisitor is the Visitor of a fieldProcessor converting Markdown.
+type markdownVisitor struct {
+	fieldproc *fieldProcessor
+}
+
+func (v markdownVisitor) Visit(_ FieldInfo, value string) (string, error) {
+	return v.fieldproc.renderString(value)
+}
+
+// rendersMarkdown reports whether the fieldProcessor renders Markdown, rather
+// than walking fields with another Visitor.
+func (f *fieldProcessor) rendersMarkdown() bool {
+	_, ok := f.visitor.(markdownVisitor)
+	return ok
+}
@@ -0,0 +1,119 @@
+package markstruct
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yuin/goldmark"
+)
+
+func trim(field FieldInfo, value string) (string, error) {
+	return strings.TrimSpace(value), nil
+}
+
+func TestTransformFields(t *testing.T) {
+	type Details struct {
+		Note string `markdown:"on"`
+	}
+
+	type Post struct {
+		Title   string            `markdown:"on"`
+		Tags    []string          `markdown:"on"`
+		Meta    map[string]string `markdown:"on"`
+		Summary *string           `markdown:"on"`
+		Author  string
+		Details Details
+	}
+
+	summary := " summary "
+	test := &Post{
+		Title:   " title ",
+		Tags:    []string{" a ", "b"},
+		Meta:    map[string]string{"k": " v "},
+		Summary: &summary,
+		Author:  " author ",
+		Details: Details{Note: " note "},
+	}
+
+	var paths []string
+	changed, err := TransformFields(test, func(field FieldInfo, value string) (string, error) {
+		paths = append(paths, field.Path)
+		return trim(field, value)
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Title", "Tags[0]", "Tags[1]", "Meta[k]", "Summary", "Details.Note"}, paths)
+	assert.Equal(t, "title", test.Title)
+	assert.Equal(t, []string{"a", "b"}, test.Tags)
+	assert.Equal(t, map[string]string{"k": "v"}, test.Meta)
+	assert.Equal(t, "summary", summary)
+	assert.Equal(t, " author ", test.Author)
+	assert.Equal(t, "note", test.Details.Note)
+
+	changed, err = TransformFields(test, trim)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+}
+
+func TestTransformAllFields(t *testing.T) {
+	type Post struct {
+		Title  string `markdown:"on"`
+		Author string
+	}
+
+	test := &Post{Title: " title ", Author: " author "}
+
+	changed, err := TransformAllFields(test, trim)
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, &Post{Title: "title", Author: "author"}, test)
+}
+
+func TestWalkErrors(t *testing.T) {
+	fail := VisitorFunc(func(field FieldInfo, value string) (string, error) {
+		if value == "BOOM" {
+			return "", errors.New("BOOM")
+		}
+		return strings.ToUpper(value), nil
+	})
+
+	changed, err := Walk(newBulkImport(), fail)
+	assert.True(t, changed)
+
+	var ferr *FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "Tags[1]", ferr.Path)
+
+	conv := WithMarkdown(goldmark.New(), WithAtomic(true))
+
+	test := newBulkImport()
+	test.Title = "title"
+
+	_, err = conv.Walk(test, fail)
+	assert.Error(t, err)
+	assert.Equal(t, "title", test.Title)
+
+	conv = WithMarkdown(goldmark.New(), WithErrorPolicy(ContinueOnError))
+
+	test = newBulkImport()
+	test.Title = "title"
+
+	changed, err = conv.Walk(test, fail)
+	assert.True(t, changed)
+	assert.EqualError(t, err, "Tags[1]: BOOM; Notes[x]: BOOM")
+	assert.Equal(t, "TITLE", test.Title)
+	assert.Equal(t, []string{"*A*", "BOOM", "*C*"}, test.Tags)
+}
+
+func TestWalkInvalid(t *testing.T) {
+	changed, err := Walk(nil, VisitorFunc(trim))
+	assert.False(t, changed)
+	assert.NoError(t, err)
+
+	_, err = Walk(BulkImport{}, VisitorFunc(trim))
+	assert.True(t, errors.Is(err, ErrInvalidType))
+}